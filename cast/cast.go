@@ -76,3 +76,19 @@ func To[T Basic](i any) T {
 
 	return v
 }
+
+// PathError records the element of a container that failed to cast.
+type PathError struct {
+	Path string // Path of the element, like [2] or [key]
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *PathError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,547 @@
+package cast
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DecodeHook converts an input value to its target type, see [RegisterDecodeHook].
+type DecodeHook func(input any) (any, error)
+
+// DecodeOption represents the options of [Decode].
+type DecodeOption struct {
+	// TagName is the struct tag holding field names and options, default "json".
+	TagName string
+	// WeaklyTyped enables lenient conversions between strings, bools and numbers,
+	// and from single values or comma-separated strings to slices.
+	WeaklyTyped bool
+	// Strict converts numbers with StrictToNumberE, failing on truncation and
+	// precision loss instead of only on overflow.
+	Strict bool
+	// Hooks are conversion hooks per target type, they take precedence over
+	// hooks registered with RegisterDecodeHook.
+	Hooks map[reflect.Type]DecodeHook
+}
+
+var (
+	decodeHooks   = map[reflect.Type]DecodeHook{}
+	decodeHooksMu sync.RWMutex
+
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// WithTagName sets the struct tag holding field names and options.
+func WithTagName(tagName string) func(*DecodeOption) {
+	return func(o *DecodeOption) {
+		o.TagName = tagName
+	}
+}
+
+// WithWeaklyTyped sets whether lenient conversions are enabled.
+func WithWeaklyTyped(weaklyTyped bool) func(*DecodeOption) {
+	return func(o *DecodeOption) {
+		o.WeaklyTyped = weaklyTyped
+	}
+}
+
+// WithStrict sets whether numbers are converted with [StrictToNumberE].
+func WithStrict(strict bool) func(*DecodeOption) {
+	return func(o *DecodeOption) {
+		o.Strict = strict
+	}
+}
+
+// WithDecodeHook adds a conversion hook to the target type T for a single call.
+func WithDecodeHook[T any](fn func(input any) (T, error)) func(*DecodeOption) {
+	return func(o *DecodeOption) {
+		if o.Hooks == nil {
+			o.Hooks = map[reflect.Type]DecodeHook{}
+		}
+
+		o.Hooks[reflect.TypeFor[T]()] = func(input any) (any, error) {
+			return fn(input)
+		}
+	}
+}
+
+// RegisterDecodeHook registers a conversion hook to the target type T for all
+// calls of [Decode], replacing the previous hook of T.
+func RegisterDecodeHook[T any](fn func(input any) (T, error)) {
+	decodeHooksMu.Lock()
+	defer decodeHooksMu.Unlock()
+
+	decodeHooks[reflect.TypeFor[T]()] = func(input any) (any, error) {
+		return fn(input)
+	}
+}
+
+// Decode decodes input, usually a map[string]any, into the value out points to.
+//
+// Struct fields are matched by the name in the tag, or the field name, exactly
+// first and then case-insensitively. Tag options are:
+//
+//	squash  decode the fields of a struct field from the parent map
+//	remain  collect the keys not matched by other fields into a map[string]any field
+//
+// Embedded structs without a tag name are squashed. Fields tagged "-" and
+// unexported fields are skipped, missing keys leave fields untouched.
+//
+// Values are converted with the hooks of their target type first, then with
+// [encoding.TextUnmarshaler] for strings, then by kind. All failing fields
+// are reported as [PathError] with their path like "servers[0].port".
+func Decode(input, out any, opts ...func(*DecodeOption)) error {
+	option := &DecodeOption{TagName: "json"}
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	v := reflect.ValueOf(out)
+	if err := ValidatePtr(v); err != nil {
+		return err
+	}
+
+	d := &decoder{option: option}
+
+	return d.decode("", input, v.Elem())
+}
+
+type decoder struct {
+	option *DecodeOption
+}
+
+// hook returns the conversion hook of t
+func (d *decoder) hook(t reflect.Type) (DecodeHook, bool) {
+	if hook, ok := d.option.Hooks[t]; ok {
+		return hook, true
+	}
+
+	decodeHooksMu.RLock()
+	defer decodeHooksMu.RUnlock()
+
+	hook, ok := decodeHooks[t]
+
+	return hook, ok
+}
+
+func (d *decoder) decode(path string, input any, out reflect.Value) error {
+	if hook, ok := d.hook(out.Type()); ok {
+		v, err := hook(input)
+		if err != nil {
+			return pathError(path, err)
+		}
+
+		return d.set(path, v, out)
+	}
+
+	input, _ = indirect(input)
+	if input == nil {
+		return nil
+	}
+
+	if out.Kind() == reflect.Ptr {
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+
+		return d.decode(path, input, out.Elem())
+	}
+
+	switch out.Type() {
+	case timeType:
+		return decodeBasic(d, path, input, out, ToTimeE)
+	case durationType:
+		return decodeBasic(d, path, input, out, ToDurationE)
+	}
+
+	if s, ok := input.(string); ok && out.CanAddr() {
+		if u, ok := out.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return pathError(path, u.UnmarshalText([]byte(s)))
+		}
+	}
+
+	switch out.Kind() {
+	case reflect.Interface:
+		return d.set(path, input, out)
+	case reflect.String:
+		return decodeBasic(d, path, input, out, ToStringE)
+	case reflect.Bool:
+		return decodeBasic(d, path, input, out, ToBoolE)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return decodeBasic(d, path, input, out, numberFunc(d, ToInt64E))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return decodeBasic(d, path, input, out, numberFunc(d, ToUint64E))
+	case reflect.Float32, reflect.Float64:
+		return decodeBasic(d, path, input, out, numberFunc(d, ToFloat64E))
+	case reflect.Slice:
+		return d.decodeSlice(path, input, out)
+	case reflect.Array:
+		return d.decodeArray(path, input, out)
+	case reflect.Map:
+		return d.decodeMap(path, input, out)
+	case reflect.Struct:
+		return d.decodeStruct(path, input, out)
+	default:
+		return d.set(path, input, out)
+	}
+}
+
+// numberFunc returns StrictToNumberE when numbers are converted strictly, fn otherwise
+func numberFunc[T Number](d *decoder, fn func(any) (T, error)) func(any) (T, error) {
+	if d.option.Strict {
+		return StrictToNumberE[T]
+	}
+
+	return fn
+}
+
+// set assigns v to out when its type is assignable or convertible
+func (d *decoder) set(path string, v any, out reflect.Value) error {
+	if v == nil {
+		out.Set(reflect.Zero(out.Type()))
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Type().AssignableTo(out.Type()):
+		out.Set(rv)
+	case rv.Type().ConvertibleTo(out.Type()) && rv.Kind() == out.Kind():
+		out.Set(rv.Convert(out.Type()))
+	default:
+		return pathError(path, fmt.Errorf(errorMsg, v, v, out.Interface()))
+	}
+
+	return nil
+}
+
+// decodeBasic decodes a scalar with fn, a strictly typed decoder requires the
+// input to be of the same kind as the target
+func decodeBasic[T any](d *decoder, path string, input any, out reflect.Value, fn func(any) (T, error)) error {
+	if !d.option.WeaklyTyped && !sameBasicKind(input, out.Type()) {
+		return pathError(path, fmt.Errorf(errorMsg, input, input, out.Interface()))
+	}
+
+	v, err := fn(input)
+	if err != nil {
+		return pathError(path, err)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.CanInt() && out.OverflowInt(rv.Int()) ||
+		rv.CanUint() && out.OverflowUint(rv.Uint()) ||
+		rv.CanFloat() && out.OverflowFloat(rv.Float()) {
+		return pathError(path, fmt.Errorf("value %v overflows %s", v, out.Type()))
+	}
+
+	out.Set(rv.Convert(out.Type()))
+
+	return nil
+}
+
+// sameBasicKind reports whether input is of the same kind as the scalar type t,
+// numbers of any kind are of the same kind
+func sameBasicKind(input any, t reflect.Type) bool {
+	switch t {
+	case timeType, durationType:
+		return true
+	}
+
+	rv := reflect.ValueOf(input)
+	if _, ok := input.(json.Number); ok {
+		return isNumberKind(t.Kind())
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return rv.Kind() == reflect.String
+	case reflect.Bool:
+		return rv.Kind() == reflect.Bool
+	default:
+		return isNumberKind(rv.Kind()) && isNumberKind(t.Kind())
+	}
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+func (d *decoder) decodeSlice(path string, input any, out reflect.Value) error {
+	if s, ok := input.(string); ok && out.Type().Elem().Kind() == reflect.Uint8 {
+		out.SetBytes([]byte(s))
+		return nil
+	}
+
+	items, err := d.sliceItems(input, out.Type())
+	if err != nil {
+		return pathError(path, err)
+	}
+
+	slice := reflect.MakeSlice(out.Type(), items.Len(), items.Len())
+	var errs []error
+	for j := 0; j < items.Len(); j++ {
+		errs = append(errs, d.decode(path+"["+strconv.Itoa(j)+"]", items.Index(j).Interface(), slice.Index(j)))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	out.Set(slice)
+
+	return nil
+}
+
+func (d *decoder) decodeArray(path string, input any, out reflect.Value) error {
+	items, err := d.sliceItems(input, out.Type())
+	if err != nil {
+		return pathError(path, err)
+	}
+
+	if items.Len() > out.Len() {
+		return pathError(path, fmt.Errorf("expect at most %d elements, got %d", out.Len(), items.Len()))
+	}
+
+	array := reflect.New(out.Type()).Elem()
+	var errs []error
+	for j := 0; j < items.Len(); j++ {
+		errs = append(errs, d.decode(path+"["+strconv.Itoa(j)+"]", items.Index(j).Interface(), array.Index(j)))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	out.Set(array)
+
+	return nil
+}
+
+// sliceItems returns the items of input to decode into a slice or array of type t,
+// a weakly typed decoder also splits strings and wraps single values
+func (d *decoder) sliceItems(input any, t reflect.Type) (reflect.Value, error) {
+	rv := reflect.ValueOf(input)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rv, nil
+	}
+
+	if !d.option.WeaklyTyped {
+		return reflect.Value{}, fmt.Errorf(errorMsg, input, input, reflect.Zero(t).Interface())
+	}
+
+	if s, ok := input.(string); ok {
+		items, err := splitListString(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf(errorMsgWith, input, input, reflect.Zero(t).Interface(), err)
+		}
+
+		return reflect.ValueOf(items), nil
+	}
+
+	return reflect.ValueOf([]any{input}), nil
+}
+
+func (d *decoder) decodeMap(path string, input any, out reflect.Value) error {
+	rv := reflect.ValueOf(input)
+	if rv.Kind() != reflect.Map {
+		return pathError(path, fmt.Errorf(errorMsg, input, input, out.Interface()))
+	}
+
+	t := out.Type()
+	m := reflect.MakeMapWithSize(t, rv.Len())
+	var errs []error
+	iter := rv.MapRange()
+	for iter.Next() {
+		keyPath := joinPath(path, fmt.Sprint(iter.Key().Interface()))
+
+		key := reflect.New(t.Key()).Elem()
+		if err := d.decode(keyPath, iter.Key().Interface(), key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		val := reflect.New(t.Elem()).Elem()
+		if err := d.decode(keyPath, iter.Value().Interface(), val); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		m.SetMapIndex(key, val)
+	}
+
+	if len(errs) > 0 {
+		// Map iteration order is random, keep errors stable
+		sort.Slice(errs, func(a, b int) bool {
+			return errs[a].Error() < errs[b].Error()
+		})
+
+		return errors.Join(errs...)
+	}
+
+	out.Set(m)
+
+	return nil
+}
+
+func (d *decoder) decodeStruct(path string, input any, out reflect.Value) error {
+	rv := reflect.ValueOf(input)
+	if rv.Type() == out.Type() {
+		out.Set(rv)
+		return nil
+	}
+
+	if rv.Kind() != reflect.Map {
+		return pathError(path, fmt.Errorf(errorMsg, input, input, out.Interface()))
+	}
+
+	m := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := ToStringE(iter.Key().Interface())
+		if err != nil {
+			return pathError(path, err)
+		}
+
+		m[key] = iter.Value().Interface()
+	}
+
+	s := &structState{input: m, used: make(map[string]bool, len(m))}
+	d.decodeFields(path, out, s)
+
+	if s.remain.IsValid() {
+		remain := map[string]any{}
+		for key, val := range m {
+			if !s.used[key] {
+				remain[key] = val
+			}
+		}
+
+		if len(remain) > 0 {
+			if err := d.decode(path, remain, s.remain); err != nil {
+				s.errs = append(s.errs, err)
+			}
+		}
+	}
+
+	return errors.Join(s.errs...)
+}
+
+// structState is the state of decoding a map into a struct and its squashed fields
+type structState struct {
+	input  map[string]any
+	used   map[string]bool
+	remain reflect.Value
+	errs   []error
+}
+
+func (d *decoder) decodeFields(path string, out reflect.Value, s *structState) {
+	t := out.Type()
+	for j := 0; j < t.NumField(); j++ {
+		field := t.Field(j)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get(d.option.TagName), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		fieldValue := out.Field(j)
+		fieldType := Deref(field.Type)
+
+		squash := hasTagOption(opts, "squash") || field.Anonymous && name == ""
+		if squash && fieldType.Kind() == reflect.Struct && fieldType != timeType {
+			if field.Type.Kind() == reflect.Ptr {
+				if !field.IsExported() {
+					continue
+				}
+
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(fieldType))
+				}
+
+				fieldValue = fieldValue.Elem()
+			}
+
+			d.decodeFields(path, fieldValue, s)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if hasTagOption(opts, "remain") {
+			s.remain = fieldValue
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		key, ok := findKey(s.input, name)
+		if !ok {
+			continue
+		}
+
+		s.used[key] = true
+		if err := d.decode(joinPath(path, name), s.input[key], fieldValue); err != nil {
+			s.errs = append(s.errs, err)
+		}
+	}
+}
+
+// findKey finds the key of m matching name exactly first and then case-insensitively
+func findKey(m map[string]any, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// pathError wraps err with path unless it is nil or the path is empty
+func pathError(path string, err error) error {
+	if err == nil || path == "" {
+		return err
+	}
+
+	return &PathError{Path: path, Err: err}
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -28,6 +29,23 @@ func (f TimeFormat) HasTimezone() bool {
 	return f.Typ >= TimeFormatNumericTimezone && f.Typ <= TimeFormatNumericAndNamedTimezone
 }
 
+// NewTimeFormat creates a TimeFormat of a custom layout, typed by its timezone elements.
+func NewTimeFormat(layout string) TimeFormat {
+	numeric := strings.Contains(layout, "-07") || strings.Contains(layout, "Z07")
+	named := strings.Contains(layout, "MST")
+
+	switch {
+	case numeric && named:
+		return TimeFormat{layout, TimeFormatNumericAndNamedTimezone}
+	case numeric:
+		return TimeFormat{layout, TimeFormatNumericTimezone}
+	case named:
+		return TimeFormat{layout, TimeFormatNamedTimezone}
+	default:
+		return TimeFormat{layout, TimeFormatNoTimezone}
+	}
+}
+
 var TimeFormats = []TimeFormat{
 	// Keep common formats at the top.
 	{"2006-01-02", TimeFormatNoTimezone},
@@ -2,8 +2,12 @@ package cast
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 func toMapE[K comparable, V any](i any, keyFn func(any) K, valFn func(any) V) (map[K]V, error) {
@@ -217,3 +221,116 @@ func jsonStringToObject(s string, v any) error {
 	data := []byte(s)
 	return json.Unmarshal(data, v)
 }
+
+// ToMapOfE casts any value to a map[K]V type key- and value-wise with [ToE].
+//
+// It accepts maps of any key and value types, JSON object strings and
+// comma-separated key=value strings. Entries failing to cast are reported as
+// [PathError] with their key.
+func ToMapOfE[K, V Basic](i any) (map[K]V, error) {
+	i, _ = indirect(i)
+	if i == nil {
+		return nil, fmt.Errorf(errorMsg, i, i, map[K]V{})
+	}
+
+	switch v := i.(type) {
+	case map[K]V:
+		return maps.Clone(v), nil
+	case string:
+		entries, err := splitMapString(v)
+		if err != nil {
+			return nil, fmt.Errorf(errorMsgWith, i, i, map[K]V{}, err)
+		}
+
+		return toMapOfE[K, V](entries)
+	}
+
+	if reflect.TypeOf(i).Kind() != reflect.Map {
+		return nil, fmt.Errorf(errorMsg, i, i, map[K]V{})
+	}
+
+	m := reflect.ValueOf(i)
+	entries := make(map[any]any, m.Len())
+	iter := m.MapRange()
+	for iter.Next() {
+		entries[iter.Key().Interface()] = iter.Value().Interface()
+	}
+
+	return toMapOfE[K, V](entries)
+}
+
+// ToMapOf casts any value to a map[K]V type key- and value-wise with [ToE].
+func ToMapOf[K, V Basic](i any) map[K]V {
+	v, _ := ToMapOfE[K, V](i)
+
+	return v
+}
+
+func toMapOfE[K, V Basic](entries map[any]any) (map[K]V, error) {
+	m := make(map[K]V, len(entries))
+
+	var errs []error
+	for key, val := range entries {
+		path := "[" + fmt.Sprint(key) + "]"
+
+		k, err := ToE[K](key)
+		if err != nil {
+			errs = append(errs, &PathError{Path: path, Err: fmt.Errorf("key: %w", err)})
+			continue
+		}
+
+		v, err := ToE[V](val)
+		if err != nil {
+			errs = append(errs, &PathError{Path: path, Err: err})
+			continue
+		}
+
+		m[k] = v
+	}
+
+	if len(errs) > 0 {
+		// Map iteration order is random, keep errors stable
+		sort.Slice(errs, func(a, b int) bool {
+			return errs[a].(*PathError).Path < errs[b].(*PathError).Path
+		})
+
+		return nil, errors.Join(errs...)
+	}
+
+	return m, nil
+}
+
+// splitMapString splits a JSON object string or comma-separated key=value string into its entries.
+func splitMapString(s string) (map[any]any, error) {
+	s = strings.TrimSpace(s)
+	entries := map[any]any{}
+	if s == "" {
+		return entries, nil
+	}
+
+	if strings.HasPrefix(s, "{") {
+		var m map[string]any
+		decoder := json.NewDecoder(strings.NewReader(s))
+		decoder.UseNumber()
+		if err := decoder.Decode(&m); err != nil {
+			return nil, err
+		}
+
+		for k, v := range m {
+			entries[k] = v
+		}
+
+		return entries, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expect key=value, got %q", strings.TrimSpace(pair))
+		}
+
+		entries[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	return entries, nil
+}
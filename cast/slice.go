@@ -1,8 +1,12 @@
 package cast
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -99,3 +103,100 @@ func ToStringSliceE(i any) ([]string, error) {
 		return nil, fmt.Errorf(errorMsg, i, i, a)
 	}
 }
+
+// ToSliceOfE casts any value to a []T type element-wise with [ToE].
+//
+// It accepts slices and arrays of any element type, JSON array strings and
+// comma-separated strings, a single value becomes a one-element slice. Elements
+// failing to cast are reported as [PathError] with their index.
+func ToSliceOfE[T Basic](i any) ([]T, error) {
+	i, _ = indirect(i)
+	if i == nil {
+		return nil, fmt.Errorf(errorMsg, i, i, []T{})
+	}
+
+	switch v := i.(type) {
+	case []T:
+		return slices.Clone(v), nil
+	case string:
+		items, err := splitListString(v)
+		if err != nil {
+			return nil, fmt.Errorf(errorMsgWith, i, i, []T{}, err)
+		}
+
+		return toSliceOfE[T](items)
+	}
+
+	switch reflect.TypeOf(i).Kind() {
+	case reflect.Slice, reflect.Array:
+		s := reflect.ValueOf(i)
+		items := make([]any, s.Len())
+		for j := range items {
+			items[j] = s.Index(j).Interface()
+		}
+
+		return toSliceOfE[T](items)
+	default:
+		v, err := ToE[T](i)
+		if err != nil {
+			return nil, err
+		}
+
+		return []T{v}, nil
+	}
+}
+
+// ToSliceOf casts any value to a []T type element-wise with [ToE].
+func ToSliceOf[T Basic](i any) []T {
+	v, _ := ToSliceOfE[T](i)
+
+	return v
+}
+
+func toSliceOfE[T Basic](items []any) ([]T, error) {
+	a := make([]T, len(items))
+
+	var errs []error
+	for j, item := range items {
+		v, err := ToE[T](item)
+		if err != nil {
+			errs = append(errs, &PathError{Path: "[" + strconv.Itoa(j) + "]", Err: err})
+			continue
+		}
+
+		a[j] = v
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return a, nil
+}
+
+// splitListString splits a JSON array string or a comma-separated string into its items.
+func splitListString(s string) ([]any, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return []any{}, nil
+	}
+
+	if strings.HasPrefix(s, "[") {
+		var items []any
+		decoder := json.NewDecoder(strings.NewReader(s))
+		decoder.UseNumber()
+		if err := decoder.Decode(&items); err != nil {
+			return nil, err
+		}
+
+		return items, nil
+	}
+
+	parts := strings.Split(s, ",")
+	items := make([]any, len(parts))
+	for j, part := range parts {
+		items[j] = strings.TrimSpace(part)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,324 @@
+package cast
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrOverflow reports a value out of the range of the target type.
+	ErrOverflow = errors.New("value out of range")
+	// ErrTruncated reports a float with a fractional part cast to an integer type.
+	ErrTruncated = errors.New("fractional part would be truncated")
+	// ErrPrecisionLoss reports an integer not exactly representable by the target float type.
+	ErrPrecisionLoss = errors.New("value not exactly representable")
+	// ErrNotFinite reports a NaN or Inf float.
+	ErrNotFinite = errors.New("value is NaN or Inf")
+	// ErrNegative reports a negative value cast to an unsigned type.
+	ErrNegative = errors.New("negative value to unsigned type")
+	// ErrSyntax reports a string not exactly parsed as the target type.
+	ErrSyntax = errors.New("invalid syntax")
+)
+
+// StrictToE casts any value to a [Basic] type like [ToE], but fails instead of
+// losing information, see [StrictToNumberE].
+//
+// Bools accept numbers only when they are 0 or 1, durations cast from numbers
+// follow the rules of int64.
+func StrictToE[T Basic](i any) (T, error) {
+	var t T
+
+	var v any
+	var err error
+
+	switch any(t).(type) {
+	case string:
+		v, err = ToStringE(i)
+	case bool:
+		v, err = strictToBoolE(i)
+	case int:
+		v, err = StrictToNumberE[int](i)
+	case int8:
+		v, err = StrictToNumberE[int8](i)
+	case int16:
+		v, err = StrictToNumberE[int16](i)
+	case int32:
+		v, err = StrictToNumberE[int32](i)
+	case int64:
+		v, err = StrictToNumberE[int64](i)
+	case uint:
+		v, err = StrictToNumberE[uint](i)
+	case uint8:
+		v, err = StrictToNumberE[uint8](i)
+	case uint16:
+		v, err = StrictToNumberE[uint16](i)
+	case uint32:
+		v, err = StrictToNumberE[uint32](i)
+	case uint64:
+		v, err = StrictToNumberE[uint64](i)
+	case float32:
+		v, err = StrictToNumberE[float32](i)
+	case float64:
+		v, err = StrictToNumberE[float64](i)
+	case time.Time:
+		v, err = ToTimeE(i)
+	case time.Duration:
+		v, err = strictToDurationE(i)
+	}
+
+	if err != nil {
+		return t, err
+	}
+
+	return v.(T), nil
+}
+
+// StrictTo casts any value to a [Basic] type like [StrictToE], the zero value is returned on failure.
+func StrictTo[T Basic](i any) T {
+	v, _ := StrictToE[T](i)
+
+	return v
+}
+
+// StrictToNumberE casts any value to a [Number] type like [ToNumberE], but fails
+// with [ErrOverflow], [ErrTruncated], [ErrPrecisionLoss], [ErrNotFinite],
+// [ErrNegative] or [ErrSyntax] instead of losing information.
+//
+// Strings are parsed exactly, e.g. "1.0" casts to int but "1.9", "" and "1e400" fail.
+func StrictToNumberE[T Number](i any) (T, error) {
+	var t T
+
+	i, _ = indirect(i)
+	if i == nil {
+		return t, nil
+	}
+
+	var (
+		v   T
+		err error
+	)
+	switch s := i.(type) {
+	case T:
+		return s, nil
+	case string:
+		v, err = strictParseNumber[T](s)
+	case json.Number:
+		v, err = strictParseNumber[T](string(s))
+	case bool:
+		if s {
+			return 1, nil
+		}
+
+		return 0, nil
+	default:
+		rv := reflect.ValueOf(i)
+		switch {
+		case rv.CanInt():
+			v, err = strictFromInt[T](rv.Int())
+		case rv.CanUint():
+			v, err = strictFromUint[T](rv.Uint())
+		case rv.CanFloat():
+			v, err = strictFromFloat[T](rv.Float())
+		default:
+			return t, fmt.Errorf(errorMsg, i, i, t)
+		}
+	}
+
+	if err != nil {
+		return t, fmt.Errorf(errorMsgWith, i, i, t, err)
+	}
+
+	return v, nil
+}
+
+// StrictToNumber casts any value to a [Number] type like [StrictToNumberE], the zero value is returned on failure.
+func StrictToNumber[T Number](i any) T {
+	v, _ := StrictToNumberE[T](i)
+
+	return v
+}
+
+func strictFromInt[T Number](v int64) (T, error) {
+	kind := reflect.TypeFor[T]().Kind()
+	if v < 0 && isUnsignedKind(kind) {
+		return 0, ErrNegative
+	}
+
+	n := T(v)
+	if isFloatKind(kind) {
+		// Beyond 2^53 not every integer has a float representation
+		if f := float64(n); f >= math.Ldexp(1, 63) || int64(f) != v {
+			return 0, ErrPrecisionLoss
+		}
+
+		return n, nil
+	}
+
+	if int64(n) != v {
+		return 0, ErrOverflow
+	}
+
+	return n, nil
+}
+
+func strictFromUint[T Number](v uint64) (T, error) {
+	kind := reflect.TypeFor[T]().Kind()
+
+	n := T(v)
+	if isFloatKind(kind) {
+		if f := float64(n); f >= math.Ldexp(1, 64) || uint64(f) != v {
+			return 0, ErrPrecisionLoss
+		}
+
+		return n, nil
+	}
+
+	if uint64(n) != v || n < 0 {
+		return 0, ErrOverflow
+	}
+
+	return n, nil
+}
+
+func strictFromFloat[T Number](v float64) (T, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, ErrNotFinite
+	}
+
+	t := reflect.TypeFor[T]()
+	bits := t.Bits()
+
+	switch kind := t.Kind(); {
+	case isFloatKind(kind):
+		if kind == reflect.Float32 && math.Abs(v) > math.MaxFloat32 {
+			return 0, ErrOverflow
+		}
+	case v != math.Trunc(v):
+		return 0, ErrTruncated
+	case isUnsignedKind(kind):
+		if v < 0 {
+			return 0, ErrNegative
+		}
+		if v >= math.Ldexp(1, bits) {
+			return 0, ErrOverflow
+		}
+	default:
+		if v < -math.Ldexp(1, bits-1) || v >= math.Ldexp(1, bits-1) {
+			return 0, ErrOverflow
+		}
+	}
+
+	return T(v), nil
+}
+
+// strictParseNumber parses s exactly, integers fall back to float syntax like
+// "1e3" or "2.0" as long as no fractional part is lost
+func strictParseNumber[T Number](s string) (T, error) {
+	t := reflect.TypeFor[T]()
+	if s == "" {
+		return 0, ErrSyntax
+	}
+
+	if isFloatKind(t.Kind()) {
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return 0, strictParseError(err)
+		}
+
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, ErrNotFinite
+		}
+
+		return T(f), nil
+	}
+
+	if isUnsignedKind(t.Kind()) {
+		v, err := strconv.ParseUint(strings.TrimPrefix(s, "+"), 0, t.Bits())
+		if err == nil {
+			return T(v), nil
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, ErrOverflow
+		}
+	} else {
+		v, err := strconv.ParseInt(s, 0, t.Bits())
+		if err == nil {
+			return T(v), nil
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, ErrOverflow
+		}
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, strictParseError(err)
+	}
+
+	return strictFromFloat[T](f)
+}
+
+func strictParseError(err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return ErrOverflow
+	}
+
+	return ErrSyntax
+}
+
+func strictToBoolE(i any) (bool, error) {
+	i, _ = indirect(i)
+
+	switch b := i.(type) {
+	case bool, nil, string:
+		return ToBoolE(b)
+	}
+
+	n, err := StrictToNumberE[float64](i)
+	if err != nil {
+		return false, fmt.Errorf(errorMsgWith, i, i, false, err)
+	}
+
+	switch n {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf(errorMsgWith, i, i, false, ErrOverflow)
+	}
+}
+
+func strictToDurationE(i any) (time.Duration, error) {
+	i, _ = indirect(i)
+
+	switch d := i.(type) {
+	case time.Duration, nil:
+		return ToDurationE(d)
+	case string:
+		if hasDurationUnit(d) {
+			return ToDurationE(d)
+		}
+	}
+
+	n, err := StrictToNumberE[int64](i)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(n), nil
+}
+
+func isUnsignedKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
@@ -25,7 +25,7 @@ func ToTimeInDefaultLocationE(i any, location *time.Location) (tim time.Time, er
 	case time.Time:
 		return v, nil
 	case string:
-		return StringToDateInDefaultLocation(v, location)
+		return parseTime(v, &TimeOption{Location: location})
 	case json.Number:
 		// Originally this used ToInt64E, but adding string float conversion broke ToTime.
 		// the behavior of ToTime would have changed if we continued using it.
@@ -79,11 +79,11 @@ func ToDurationE(i any) (time.Duration, error) {
 
 		return time.Duration(v), nil
 	case string:
-		if !strings.ContainsAny(s, "nsuµmh") {
+		if !hasDurationUnit(s) {
 			return time.ParseDuration(s + "ns")
 		}
 
-		return time.ParseDuration(s)
+		return ParseDuration(s)
 	case nil:
 		return time.Duration(0), nil
 	default:
@@ -95,17 +95,17 @@ func ToDurationE(i any) (time.Duration, error) {
 	}
 }
 
-// StringToDate attempts to parse a string into a [time.Time] type using a
-// predefined list of formats.
+// StringToDate attempts to parse a string into a [time.Time] type using the
+// layouts registered with [RegisterTimeLayout] and a predefined list of formats.
 //
 // If no suitable format is found, an error is returned.
 func StringToDate(s string) (time.Time, error) {
-	return internal.ParseDateWith(s, time.UTC, internal.TimeFormats)
+	return internal.ParseDateWith(s, time.UTC, timeFormats(nil))
 }
 
 // StringToDateInDefaultLocation casts an empty interface to a [time.Time],
 // interpreting inputs without a timezone to be in the given location,
 // or the local timezone if nil.
 func StringToDateInDefaultLocation(s string, location *time.Location) (time.Time, error) {
-	return internal.ParseDateWith(s, location, internal.TimeFormats)
+	return internal.ParseDateWith(s, location, timeFormats(nil))
 }
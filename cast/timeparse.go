@@ -0,0 +1,233 @@
+package cast
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meta-apex/gopkg/cast/internal"
+)
+
+// Day and Week are the duration units added by [ParseDuration].
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+var (
+	timeLayouts   []internal.TimeFormat
+	timeLayoutsMu sync.RWMutex
+)
+
+// TimeOption represents the options of [ToTimeWithE].
+type TimeOption struct {
+	// Location interprets inputs without a timezone, the local timezone if nil.
+	Location *time.Location
+	// Layouts are tried before the registered and predefined layouts.
+	Layouts []string
+	// Now returns the current time of relative expressions, time.Now if nil.
+	Now func() time.Time
+}
+
+// WithLocation sets the location interpreting inputs without a timezone.
+func WithLocation(location *time.Location) func(*TimeOption) {
+	return func(o *TimeOption) {
+		o.Location = location
+	}
+}
+
+// WithLayouts adds layouts tried before the registered and predefined layouts.
+func WithLayouts(layouts ...string) func(*TimeOption) {
+	return func(o *TimeOption) {
+		o.Layouts = append(o.Layouts, layouts...)
+	}
+}
+
+// WithNow sets the function returning the current time of relative expressions.
+func WithNow(now func() time.Time) func(*TimeOption) {
+	return func(o *TimeOption) {
+		o.Now = now
+	}
+}
+
+// RegisterTimeLayout registers layouts tried by all time casts before the
+// predefined layouts, in the order of registration.
+func RegisterTimeLayout(layouts ...string) {
+	timeLayoutsMu.Lock()
+	defer timeLayoutsMu.Unlock()
+
+	for _, layout := range layouts {
+		timeLayouts = append(timeLayouts, internal.NewTimeFormat(layout))
+	}
+}
+
+// ToTimeWithE casts any value to a [time.Time] type like [ToTimeE], strings
+// are parsed with the layouts and location of the options, default UTC.
+func ToTimeWithE(i any, opts ...func(*TimeOption)) (time.Time, error) {
+	option := &TimeOption{Location: time.UTC}
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	i, _ = indirect(i)
+	if s, ok := i.(string); ok {
+		return parseTime(s, option)
+	}
+
+	return ToTimeInDefaultLocationE(i, option.Location)
+}
+
+// ToTimeWith casts any value to a [time.Time] type like [ToTimeWithE].
+func ToTimeWith(i any, opts ...func(*TimeOption)) time.Time {
+	v, _ := ToTimeWithE(i, opts...)
+
+	return v
+}
+
+// parseTime parses a relative expression like "now-1h" or a time in one of
+// the layouts of option, the registered layouts or the predefined layouts
+func parseTime(s string, option *TimeOption) (time.Time, error) {
+	if t, ok, err := parseRelativeTime(s, option); ok {
+		return t, err
+	}
+
+	return internal.ParseDateWith(s, option.Location, timeFormats(option.Layouts))
+}
+
+// timeFormats returns layouts followed by the registered and predefined layouts
+func timeFormats(layouts []string) []internal.TimeFormat {
+	timeLayoutsMu.RLock()
+	defer timeLayoutsMu.RUnlock()
+
+	if len(layouts) == 0 && len(timeLayouts) == 0 {
+		return internal.TimeFormats
+	}
+
+	formats := make([]internal.TimeFormat, 0, len(layouts)+len(timeLayouts)+len(internal.TimeFormats))
+	for _, layout := range layouts {
+		formats = append(formats, internal.NewTimeFormat(layout))
+	}
+	formats = append(formats, timeLayouts...)
+
+	return append(formats, internal.TimeFormats...)
+}
+
+// parseRelativeTime parses "now" optionally followed by a signed duration of
+// ParseDuration like "now-1h" or "now + 2d12h", it reports whether s is a
+// relative expression
+func parseRelativeTime(s string, option *TimeOption) (time.Time, bool, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 3 || !strings.EqualFold(s[:3], "now") {
+		return time.Time{}, false, nil
+	}
+
+	offset := strings.ReplaceAll(s[3:], " ", "")
+	if offset != "" && offset[0] != '+' && offset[0] != '-' {
+		return time.Time{}, false, nil
+	}
+
+	now := time.Now
+	if option.Now != nil {
+		now = option.Now
+	}
+
+	t := now()
+	if option.Location != nil {
+		t = t.In(option.Location)
+	}
+	if offset == "" {
+		return t, true, nil
+	}
+
+	d, err := ParseDuration(offset)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("unable to parse relative time %q: %w", s, err)
+	}
+
+	return t.Add(d), true, nil
+}
+
+// ParseDuration parses a duration string like [time.ParseDuration], with the
+// additional units "d" for days and "w" for weeks, e.g. "2d12h" or "-1w".
+func ParseDuration(s string) (time.Duration, error) {
+	if !strings.ContainsAny(s, "dw") {
+		return time.ParseDuration(s)
+	}
+
+	orig := s
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+
+	var (
+		d    float64
+		rest strings.Builder
+	)
+	for s != "" {
+		n := strings.IndexFunc(s, func(r rune) bool { return r != '.' && (r < '0' || r > '9') })
+		if n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		num := s[:n]
+		s = s[n:]
+
+		u := strings.IndexFunc(s, func(r rune) bool { return r == '.' || r >= '0' && r <= '9' })
+		if u < 0 {
+			u = len(s)
+		}
+		unit := s[:u]
+		s = s[u:]
+
+		switch unit {
+		case "d", "w":
+			f, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+
+			if unit == "d" {
+				d += f * float64(Day)
+			} else {
+				d += f * float64(Week)
+			}
+		default:
+			rest.WriteString(num + unit)
+		}
+	}
+
+	if d >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q: %w", orig, ErrOverflow)
+	}
+	result := time.Duration(d)
+
+	if rest.Len() > 0 {
+		v, err := time.ParseDuration(rest.String())
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+
+		if result > math.MaxInt64-v {
+			return 0, fmt.Errorf("invalid duration %q: %w", orig, ErrOverflow)
+		}
+		result += v
+	}
+
+	if neg {
+		result = -result
+	}
+
+	return result, nil
+}
+
+// hasDurationUnit reports whether s contains a unit of ParseDuration
+func hasDurationUnit(s string) bool {
+	return strings.ContainsAny(s, "nsuµmhdw")
+}
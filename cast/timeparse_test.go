@@ -0,0 +1,52 @@
+package cast
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"1h30m", 90 * time.Minute},
+		{"500ms", 500 * time.Millisecond},
+		{"2d", 2 * Day},
+		{"1w", Week},
+		{"-1w", -Week},
+		{"+1d", Day},
+		{"2d12h", 2*Day + 12*time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"1w2d3h4m", Week + 2*Day + 3*time.Hour + 4*time.Minute},
+		{"1h1d", Day + time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDurationInvalid(t *testing.T) {
+	for _, in := range []string{"", "d", "-", "1x", "1dd", "1.2.3d", "abc"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestParseDurationOverflow(t *testing.T) {
+	for _, in := range []string{"200000w", "106751d24h"} {
+		_, err := ParseDuration(in)
+		if !errors.Is(err, ErrOverflow) {
+			t.Errorf("ParseDuration(%q) error = %v, want ErrOverflow", in, err)
+		}
+	}
+}
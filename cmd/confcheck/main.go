@@ -0,0 +1,24 @@
+// Command confcheck validates, prints and diffs conf config files offline.
+//
+// Usage:
+//
+//	confcheck check [-profile p] [-env-file f] config.yaml...
+//	confcheck print [-profile p] [-env-file f] [-format yaml|json] config.yaml...
+//	confcheck diff [-profile p] [-env-file f] [-format text|json] old.yaml new.yaml
+//
+// This build checks syntax, includes and ${...} references only. To validate
+// against the config structs of a service, build a main registering them:
+//
+//	func main() {
+//		confcheck.Register[app.Config]("app")
+//		confcheck.Main()
+//	}
+//
+// check exits with 1 on invalid configs, diff exits with 1 when the configs differ.
+package main
+
+import "github.com/meta-apex/gopkg/conf/confcheck"
+
+func main() {
+	confcheck.Main()
+}
@@ -0,0 +1,279 @@
+// Command confcrypt manages encrypted values of conf config files.
+//
+// Mark plain values in JSON, YAML or TOML files with the "enc:plain:" prefix,
+// then encrypt them in place:
+//
+//	password: "enc:plain:s3cret"   ->   password: "enc:v1:..."
+//
+// Usage:
+//
+//	confcrypt genkey
+//	confcrypt encrypt [-key base64 | -key-file file] config.yaml...
+//	confcrypt value [-key base64 | -key-file file] plaintext
+//	confcrypt decrypt [-key base64 | -key-file file] config.yaml
+//	confcrypt rotate [-key base64 | -key-file file] -new-key base64 | -new-key-file file config.yaml...
+//
+// The key defaults to the CONF_SECRET_KEY or CONF_SECRET_KEY_FILE environment variables.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/meta-apex/gopkg/conf"
+	"github.com/meta-apex/gopkg/internal/fileutil"
+)
+
+const plainPrefix = "enc:plain:"
+
+var (
+	doubleQuotedRegex = regexp.MustCompile(`"enc:plain:((?:[^"\\\n]|\\.)*)"`)
+	singleQuotedRegex = regexp.MustCompile(`'enc:plain:((?:[^'\n]|'')*)'`)
+	bareRegex         = regexp.MustCompile(`(^|[\s:=\-\[,])enc:plain:([^\s"'#,\]\}]+)`)
+	secretRegex       = regexp.MustCompile(`enc:v1:[A-Za-z0-9+/]+=*`)
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "genkey":
+		err = genkey()
+	case "encrypt":
+		err = encrypt(args)
+	case "value":
+		err = value(args)
+	case "decrypt":
+		err = decrypt(args)
+	case "rotate":
+		err = rotate(args)
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "confcrypt:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  confcrypt genkey
+  confcrypt encrypt [-key base64 | -key-file file] file...
+  confcrypt value [-key base64 | -key-file file] plaintext
+  confcrypt decrypt [-key base64 | -key-file file] file
+  confcrypt rotate [-key base64 | -key-file file] -new-key base64 | -new-key-file file file...`)
+	os.Exit(2)
+}
+
+func genkey() error {
+	key, err := conf.GenerateSecretKey()
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
+
+func encrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	keyFn := keyFlags(fs, "key")
+	_ = fs.Parse(args)
+
+	key, err := keyFn()
+	if err != nil {
+		return err
+	}
+
+	return rewriteFiles(fs.Args(), func(content string) (string, int, error) {
+		return encryptMarkers(content, key)
+	})
+}
+
+func value(args []string) error {
+	fs := flag.NewFlagSet("value", flag.ExitOnError)
+	keyFn := keyFlags(fs, "key")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	key, err := keyFn()
+	if err != nil {
+		return err
+	}
+
+	secret, err := conf.EncryptSecret(fs.Arg(0), key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(secret)
+	return nil
+}
+
+func decrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	keyFn := keyFlags(fs, "key")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	key, err := keyFn()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	content, _, err := replaceSecrets(string(data), func(secret string) (string, error) {
+		return conf.DecryptSecret(secret, key)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(content)
+	return nil
+}
+
+func rotate(args []string) error {
+	fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+	keyFn := keyFlags(fs, "key")
+	newKeyFn := keyFlags(fs, "new-key")
+	_ = fs.Parse(args)
+
+	key, err := keyFn()
+	if err != nil {
+		return err
+	}
+	newKey, err := newKeyFn()
+	if err != nil {
+		return fmt.Errorf("new key: %w", err)
+	}
+
+	return rewriteFiles(fs.Args(), func(content string) (string, int, error) {
+		return replaceSecrets(content, func(secret string) (string, error) {
+			plain, err := conf.DecryptSecret(secret, key)
+			if err != nil {
+				return "", err
+			}
+			return conf.EncryptSecret(plain, newKey)
+		})
+	})
+}
+
+// keyFlags registers -name and -name-file flags and returns a function resolving the key
+func keyFlags(fs *flag.FlagSet, name string) func() ([]byte, error) {
+	key := fs.String(name, "", "base64 encoded key")
+	keyFile := fs.String(name+"-file", "", "file holding the base64 encoded key")
+
+	return func() ([]byte, error) {
+		switch {
+		case *key != "":
+			return conf.ParseSecretKey(*key)
+		case *keyFile != "":
+			data, err := os.ReadFile(*keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return conf.ParseSecretKey(string(data))
+		case name == "key":
+			return conf.LoadSecretKey()
+		default:
+			return nil, fmt.Errorf("-%s or -%s-file is required", name, name)
+		}
+	}
+}
+
+// encryptMarkers encrypts every "enc:plain:" value of content, keeping its quoting
+func encryptMarkers(content string, key []byte) (string, int, error) {
+	var (
+		count int
+		err   error
+	)
+	encrypt := func(plain string) string {
+		if err != nil {
+			return ""
+		}
+		var secret string
+		secret, err = conf.EncryptSecret(plain, key)
+		count++
+		return secret
+	}
+
+	content = doubleQuotedRegex.ReplaceAllStringFunc(content, func(match string) string {
+		plain, uerr := strconv.Unquote(`"` + strings.TrimPrefix(match[1:len(match)-1], plainPrefix) + `"`)
+		if uerr != nil && err == nil {
+			err = fmt.Errorf("invalid quoted value %s: %w", match, uerr)
+		}
+		return `"` + encrypt(plain) + `"`
+	})
+	content = singleQuotedRegex.ReplaceAllStringFunc(content, func(match string) string {
+		plain := strings.ReplaceAll(strings.TrimPrefix(match[1:len(match)-1], plainPrefix), "''", "'")
+		return `'` + encrypt(plain) + `'`
+	})
+	content = bareRegex.ReplaceAllStringFunc(content, func(match string) string {
+		sub := bareRegex.FindStringSubmatch(match)
+		return sub[1] + encrypt(sub[2])
+	})
+
+	return content, count, err
+}
+
+// replaceSecrets replaces every "enc:v1:" value of content with fn
+func replaceSecrets(content string, fn func(secret string) (string, error)) (string, int, error) {
+	var (
+		count int
+		err   error
+	)
+	content = secretRegex.ReplaceAllStringFunc(content, func(secret string) string {
+		if err != nil {
+			return secret
+		}
+		var replaced string
+		replaced, err = fn(secret)
+		count++
+		return replaced
+	})
+	return content, count, err
+}
+
+// rewriteFiles applies fn to every file and atomically replaces changed files
+func rewriteFiles(files []string, fn func(content string) (string, int, error)) error {
+	if len(files) == 0 {
+		usage()
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		content, count, err := fn(string(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if count == 0 {
+			continue
+		}
+
+		if err := fileutil.WriteFileAtomic(file, []byte(content)); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d value(s) updated\n", file, count)
+	}
+
+	return nil
+}
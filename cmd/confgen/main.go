@@ -0,0 +1,83 @@
+// Command confgen generates Go config structs with meta tags from a sample
+// JSON, YAML or TOML config file.
+//
+// Usage:
+//
+//	confgen [-type Config] [-package config] [-match ignorecase|normal|camel|snake] [-o config.go] sample.yaml
+//
+// Field types are inferred from the sample values, scalar values become
+// defaults and null or empty values become optional fields. The generated
+// code is written to stdout unless -o is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/meta-apex/gopkg/conf"
+)
+
+func main() {
+	typeName := flag.String("type", "Config", "name of the root struct type")
+	pkg := flag.String("package", "config", "package name of the generated file")
+	match := flag.String("match", "ignorecase", "match mode the structs are loaded with: ignorecase, normal, camel or snake")
+	tagName := flag.String("tag", "meta", "tag name")
+	output := flag.String("o", "", "output file, stdout if empty")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: confgen [flags] sample.yaml")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *typeName, *pkg, *match, *tagName, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "confgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(sample, typeName, pkg, match, tagName, output string) error {
+	mode, err := matchMode(match)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(sample)
+	if err != nil {
+		return err
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(sample)), ".")
+	code, err := conf.GenerateStructs(data, format, pkg, typeName, conf.WithMatchMode(mode), conf.WithTagName(tagName))
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(output, code, 0644)
+}
+
+func matchMode(s string) (conf.MatchMode, error) {
+	switch strings.ToLower(s) {
+	case "ignorecase":
+		return conf.MatchIgnoreCase, nil
+	case "normal":
+		return conf.MatchNormal, nil
+	case "camel":
+		return conf.MatchCamelCase, nil
+	case "snake":
+		return conf.MatchSnakeCase, nil
+	default:
+		return 0, fmt.Errorf("unknown match mode %q", s)
+	}
+}
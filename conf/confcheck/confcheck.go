@@ -0,0 +1,361 @@
+// Package confcheck validates, prints and diffs conf config files offline,
+// e.g. in CI pipelines before a service is deployed.
+//
+// Register the config struct types in a small main package and call Main:
+//
+//	func main() {
+//		confcheck.Register[app.Config]("app")
+//		confcheck.Register[worker.Config]("worker", conf.WithAutoEnv("WORKER"))
+//		confcheck.Main()
+//	}
+//
+// Without registered types, files are checked for syntax, includes and
+// ${...} references only, and printed and diffed as raw maps.
+package confcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/meta-apex/gopkg/conf"
+	"gopkg.in/yaml.v3"
+)
+
+// rawType is the type name of the raw mode, checking files without a struct
+const rawType = "raw"
+
+// checker loads config layers of a registered type
+type checker struct {
+	name string
+	load func(files []string, opts ...func(*conf.Option)) (*conf.Config, error)
+}
+
+// checkers holds the registered types in registration order
+var checkers []*checker
+
+// Register registers config struct type T under name, opts are applied on
+// every load, e.g. the match mode or env prefix the service uses
+func Register[T any](name string, opts ...func(*conf.Option)) {
+	checkers = append(checkers, &checker{
+		name: name,
+		load: func(files []string, extra ...func(*conf.Option)) (*conf.Config, error) {
+			// Load into an isolated registry to keep loads apart
+			registry := conf.NewRegistry()
+			defer registry.Close()
+
+			loadOpts := append(append(opts[:len(opts):len(opts)], extra...), conf.WithRegistry(registry))
+			if _, err := conf.LoadLayers[T](files, loadOpts...); err != nil {
+				return nil, err
+			}
+			return conf.GetFrom[T](registry, ""), nil
+		},
+	})
+}
+
+// rawChecker loads files without a config struct
+var rawChecker = &checker{
+	name: rawType,
+	load: func(files []string, opts ...func(*conf.Option)) (*conf.Config, error) {
+		type raw struct{}
+		registry := conf.NewRegistry()
+		defer registry.Close()
+
+		if _, err := conf.LoadLayers[raw](files, append(opts, conf.WithRegistry(registry))...); err != nil {
+			return nil, err
+		}
+		return conf.GetFrom[raw](registry, ""), nil
+	},
+}
+
+// Main runs the command line with os.Args and exits with its status
+func Main() {
+	os.Exit(Run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Run runs the command line args, writing results to stdout and errors to
+// stderr. It returns 0 on success, 1 when checks fail or diffs are found,
+// and 2 on usage errors or configs diff cannot load.
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	typeName := fs.String("type", "", "registered config type, "+typeNames())
+	profile := fs.String("profile", "", "profile overlay name, e.g. prod for config.prod.yaml")
+	envFile := fs.String("env-file", "", "dotenv file to load into the environment before loading")
+	format := fs.String("format", "", "output format, yaml or json for print, text or json for diff")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	c, err := checkerOf(*typeName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if *envFile != "" {
+		if err := godotenv.Load(*envFile); err != nil {
+			fmt.Fprintf(stderr, "failed to load env file: %v\n", err)
+			return 2
+		}
+	}
+
+	var opts []func(*conf.Option)
+	if *profile != "" {
+		opts = append(opts, conf.WithProfile(*profile))
+	}
+
+	switch cmd {
+	case "check":
+		return check(c, fs.Args(), opts, stdout, stderr)
+	case "print":
+		return printConfig(c, fs.Args(), opts, *format, stdout, stderr)
+	case "diff":
+		return diff(c, fs.Args(), opts, *format, stdout, stderr)
+	default:
+		usage(stderr)
+		return 2
+	}
+}
+
+// usage prints the command line usage
+func usage(w io.Writer) {
+	fmt.Fprintln(w, `Usage:
+  confcheck check [-type name] [-profile p] [-env-file f] file...
+  confcheck print [-type name] [-profile p] [-env-file f] [-format yaml|json] file...
+  confcheck diff [-type name] [-profile p] [-env-file f] [-format text|json] old new
+
+Files are merged as layers in order, diff compares two comma separated layer lists.
+Registered types: `+typeNames())
+}
+
+// typeNames lists the registered type names
+func typeNames() string {
+	names := make([]string, 0, len(checkers)+1)
+	for _, c := range checkers {
+		names = append(names, c.name)
+	}
+	return strings.Join(append(names, rawType), ", ")
+}
+
+// checkerOf returns the checker of the type name, the only registered type
+// or raw mode when name is empty
+func checkerOf(name string) (*checker, error) {
+	if name == "" {
+		switch len(checkers) {
+		case 0:
+			return rawChecker, nil
+		case 1:
+			return checkers[0], nil
+		default:
+			return nil, fmt.Errorf("several types registered, choose one with -type: %s", typeNames())
+		}
+	}
+
+	if name == rawType {
+		return rawChecker, nil
+	}
+	for _, c := range checkers {
+		if c.name == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown type %q, registered types: %s", name, typeNames())
+}
+
+// load loads the layers with c and checks their references
+func load(c *checker, files []string, opts []func(*conf.Option)) (*conf.Config, error) {
+	config, err := c.load(files, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.CheckReferences(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// check validates the layers
+func check(c *checker, files []string, opts []func(*conf.Option), stdout, stderr io.Writer) int {
+	if len(files) == 0 {
+		fmt.Fprintln(stderr, "check: no config files given")
+		return 2
+	}
+
+	if _, err := load(c, files, opts); err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", strings.Join(files, ", "), err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "%s: ok (%s)\n", strings.Join(files, ", "), c.name)
+	return 0
+}
+
+// printConfig prints the effective merged config
+func printConfig(c *checker, files []string, opts []func(*conf.Option), format string, stdout, stderr io.Writer) int {
+	if len(files) == 0 {
+		fmt.Fprintln(stderr, "print: no config files given")
+		return 2
+	}
+	if format == "" {
+		format = "yaml"
+	}
+
+	config, err := load(c, files, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", strings.Join(files, ", "), err)
+		return 1
+	}
+
+	var data []byte
+	if c == rawChecker {
+		data, err = marshal(config.GetMap(), format)
+	} else {
+		data, err = config.Dump(format)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	stdout.Write(data)
+	return 0
+}
+
+// change is a changed path between two config versions
+type change struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"` // added, removed or changed
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// diff prints the paths changed between two config versions
+func diff(c *checker, args []string, opts []func(*conf.Option), format string, stdout, stderr io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprintln(stderr, "diff: expect old and new config files")
+		return 2
+	}
+
+	var values [2]map[string]any
+	for i, arg := range args {
+		files := strings.Split(arg, ",")
+		config, err := load(c, files, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", arg, err)
+			return 2
+		}
+		if c == rawChecker {
+			values[i] = config.GetMap()
+		} else {
+			values[i] = config.Values()
+		}
+	}
+
+	oldValues, newValues := flatten(values[0], "", nil), flatten(values[1], "", nil)
+	paths := make([]string, 0, len(oldValues)+len(newValues))
+	for path := range oldValues {
+		paths = append(paths, path)
+	}
+	for path := range newValues {
+		if _, exists := oldValues[path]; !exists {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := make([]change, 0)
+	for _, path := range paths {
+		oldValue, inOld := oldValues[path]
+		newValue, inNew := newValues[path]
+		switch {
+		case !inOld:
+			changes = append(changes, change{Path: path, Kind: "added", New: newValue})
+		case !inNew:
+			changes = append(changes, change{Path: path, Kind: "removed", Old: oldValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, change{Path: path, Kind: "changed", Old: oldValue, New: newValue})
+		}
+	}
+
+	if format == "json" {
+		data, _ := json.MarshalIndent(changes, "", "  ")
+		fmt.Fprintln(stdout, string(data))
+	} else {
+		for _, ch := range changes {
+			switch ch.Kind {
+			case "added":
+				fmt.Fprintf(stdout, "+ %s: %s\n", ch.Path, text(ch.New))
+			case "removed":
+				fmt.Fprintf(stdout, "- %s: %s\n", ch.Path, text(ch.Old))
+			default:
+				fmt.Fprintf(stdout, "~ %s: %s -> %s\n", ch.Path, text(ch.Old), text(ch.New))
+			}
+		}
+	}
+
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// flatten flattens nested maps into dotted paths, lists are kept as values
+func flatten(value any, path string, result map[string]any) map[string]any {
+	if result == nil {
+		result = make(map[string]any)
+	}
+	if m, ok := value.(map[string]any); ok && (len(m) > 0 || path == "") {
+		for key, item := range m {
+			next := key
+			if path != "" {
+				next = path + "." + key
+			}
+			flatten(item, next, result)
+		}
+		return result
+	}
+	result[path] = value
+	return result
+}
+
+// text formats a value on one line
+func text(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// marshal encodes value as yaml or json
+func marshal(value any, format string) ([]byte, error) {
+	switch format {
+	case "yaml", "yml":
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), encoder.Close()
+	case "json":
+		data, err := json.MarshalIndent(value, "", "  ")
+		return append(data, '\n'), err
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
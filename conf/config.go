@@ -1,26 +1,41 @@
 package conf
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
+
+	"github.com/meta-apex/gopkg/zlog"
 )
 
 // Config represents a configuration instance
 type Config struct {
-	rawMap  map[string]any
-	target  any
-	file    string
-	option  *Option
-	watcher *FileWatcher
-	mu      sync.RWMutex
-}
-
-// Global registry to track configs by target type
-var (
-	registryMu     sync.RWMutex
-	configRegistry = make(map[reflect.Type]*Config)
-)
+	rawMap   map[string]any
+	origins  Origins
+	target   any
+	file     string
+	files    []layer
+	included []string
+	sources  []Source
+	option   *Option
+	name     string
+	registry *Registry
+	watcher  *FileWatcher
+	cancel   context.CancelFunc
+	mu       sync.RWMutex
+
+	persistMu sync.Mutex
+
+	history     []historyEntry
+	nextVersion uint64
+
+	snapshot    atomic.Value
+	subscribers map[uint64]func(oldValue, newValue any)
+	nextSubID   uint64
+	subMu       sync.Mutex
+}
 
 // MustLoad loads configuration from file, panics on error
 func MustLoad[T any](file string, opts ...func(*Option)) *T {
@@ -52,9 +67,19 @@ func New[T any](fn func(v *Config) error, opts ...func(*Option)) (*Config, error
 		opt(option)
 	}
 
+	registry := option.Registry
+	if registry == nil {
+		registry = defaultRegistry
+	}
 	c := &Config{
-		target: v,
-		option: option,
+		target:   v,
+		option:   option,
+		name:     option.Name,
+		registry: registry,
+	}
+
+	if option.UseEnv {
+		loadEnvFile()
 	}
 
 	// Call the function to setup c
@@ -64,44 +89,125 @@ func New[T any](fn func(v *Config) error, opts ...func(*Option)) (*Config, error
 	if c.rawMap == nil {
 		return nil, fmt.Errorf("rawMap is nil")
 	}
-
-	if option.UseEnv {
-		loadEnvFile()
+	if c.origins == nil {
+		c.origins = make(Origins)
+		c.origins.setLeaves("", c.rawMap, Origin{Kind: OriginSource}, nil)
 	}
 
+	applyEnvOverrides(c.rawMap, c.origins, reflect.TypeOf(v), option)
+	applyFlagOverrides(c.rawMap, c.origins, reflect.TypeOf(v), option)
+
 	if err := mapToStruct(c.rawMap, v, option, false); err != nil {
 		return nil, err
 	}
+	if err := runValidators(v, option); err != nil {
+		return nil, err
+	}
+	// The snapshot must not share slices, maps or pointers with the target
+	// returned by Load, which callers may modify
+	c.snapshot.Store(deepCopy(reflect.ValueOf(v)).Interface())
+	c.record(Version{Source: "load"}, c.rawMap, c.origins)
 
-	targetType := reflect.TypeOf(v)
-	registryMu.Lock()
-	configRegistry[targetType] = c
-	registryMu.Unlock()
+	if err := registry.register(c); err != nil {
+		return nil, err
+	}
 
 	// Setup hot reload if enabled
-	if option.HotReload && option.Updatable && c.file != "" {
+	if option.HotReload && option.Updatable && len(c.files) > 0 {
 		watcher, err := NewFileWatcher(c)
 		if err != nil {
+			c.Close()
 			return nil, fmt.Errorf("failed to create file watcher: %w", err)
 		}
 		c.watcher = watcher
 		if err := watcher.Start(); err != nil {
+			c.Close()
 			return nil, fmt.Errorf("failed to start file watcher: %w", err)
 		}
 	}
+	if option.HotReload && option.Updatable && len(c.sources) > 0 {
+		if err := c.startSources(); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
 
 	return c, nil
 }
 
-// Load loads configuration from file
+// load loads the raw configuration map from the files or sources of c
+func (c *Config) load() (map[string]any, Origins, error) {
+	if len(c.sources) > 0 {
+		return c.loadSources()
+	}
+	return c.loadFiles()
+}
+
+// reload reloads the configuration from the files or sources of c, the last
+// good configuration is kept when loading, mapping or validation fails
+func (c *Config) reload() error {
+	rawMap, origins, err := c.load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	targetType := reflect.TypeOf(c.target)
+	applyEnvOverrides(rawMap, origins, targetType, c.option)
+	applyFlagOverrides(rawMap, origins, targetType, c.option)
+
+	return c.replace(rawMap, origins, "reload")
+}
+
+// reportError reports a hot reload error to the error handler or zlog
+func (c *Config) reportError(err error) {
+	if c.option.ErrorHandler != nil {
+		c.option.ErrorHandler(err)
+		return
+	}
+	zlog.Error().Msgf("conf: reload failed, keeping the last good config: %v", err)
+}
+
+// startSources starts watching the sources of c
+func (c *Config) startSources() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.watchSources(ctx); err != nil {
+		cancel()
+		return err
+	}
+	c.cancel = cancel
+	return nil
+}
+
+// stopSources stops watching the sources of c
+func (c *Config) stopSources() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+// Load loads configuration from file. The returned value is a copy owned by the
+// caller, updates are also written to it but read Current for a race-free view.
 func Load[T any](file string, opts ...func(*Option)) (*T, error) {
 	config, err := New[T](func(v *Config) error {
-		rawMap, err := parseConfigFile(file)
+		v.file = file
+		v.files = []layer{{file: file}}
+		rawMap, origins, err := v.loadFiles()
 		if err != nil {
 			return err
 		}
 		v.rawMap = rawMap
-		v.file = file
+		v.origins = origins
 		return nil
 	}, opts...)
 
@@ -121,6 +227,8 @@ func LoadFromJson[T any](content []byte, opts ...func(*Option)) (*T, error) {
 			return err
 		}
 		v.rawMap = rawMap
+		v.origins = make(Origins)
+		v.origins.setLeaves("", rawMap, Origin{Kind: OriginSource, Name: "json"}, nil)
 		return nil
 	}, opts...)
 
@@ -131,20 +239,9 @@ func LoadFromJson[T any](content []byte, opts ...func(*Option)) (*T, error) {
 	return config.target.(*T), nil
 }
 
-// Get gets Config instance by target type
+// Get gets the unnamed Config instance by target type from the default registry
 func Get[T any]() *Config {
-	var target *T
-	targetType := reflect.TypeOf(target)
-
-	registryMu.RLock()
-	config, exists := configRegistry[targetType]
-	registryMu.RUnlock()
-
-	if !exists {
-		return nil
-	}
-
-	return config
+	return GetNamed[T]("")
 }
 
 // GetMap returns the raw configuration map
@@ -160,7 +257,15 @@ func (c *Config) GetMap() map[string]any {
 	return result
 }
 
-// Update updates configuration with new map
+// Update updates configuration with new map.
+//
+// The map is deep-merged into the current raw map, a fresh snapshot is built
+// from the result and swapped in atomically, so readers of Current never see
+// a partially applied update. Subscribers are notified afterwards.
+//
+// With Option.Persist the updated keys are written back to the config file
+// before the snapshot is swapped in, a failed write leaves the config
+// unchanged, see WithPersist.
 func (c *Config) Update(m map[string]any) error {
 	if !c.option.Updatable {
 		return fmt.Errorf("config is not updatable")
@@ -170,15 +275,48 @@ func (c *Config) Update(m map[string]any) error {
 	if len(m) == 0 {
 		return nil
 	}
+	if c.option.Persist && c.file == "" {
+		return fmt.Errorf("config has no file to persist to")
+	}
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	rawMap := cloneValue(c.rawMap).(map[string]any)
+	mergeMaps(rawMap, m, c.option)
+	origins := c.origins.clone()
+	origins.setLeaves("", m, Origin{Kind: OriginUpdate}, nil)
+	oldValue, newValue, err := c.prepare(rawMap)
+	if err != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("failed to update struct: %w", err)
+	}
+	if c.option.Persist {
+		if err := c.persist(m); err != nil {
+			c.mu.Unlock()
+			return fmt.Errorf("failed to persist config: %w", err)
+		}
+	}
+	c.apply(rawMap, origins, Version{Source: "update"}, newValue)
+	c.mu.Unlock()
+
+	c.publish(oldValue, newValue)
+	return nil
+}
+
+// replace replaces the whole configuration with rawMap applied by source
+func (c *Config) replace(rawMap map[string]any, origins Origins, source string) error {
+	if !c.option.Updatable {
+		return fmt.Errorf("config is not updatable")
+	}
+
+	c.mu.Lock()
+	oldValue, newValue, err := c.commit(rawMap, origins, Version{Source: source})
+	c.mu.Unlock()
 
-	// Update only the fields present in the update map
-	if err := mapToStruct(m, c.target, c.option, true); err != nil {
+	if err != nil {
 		return fmt.Errorf("failed to update struct: %w", err)
 	}
 
+	c.publish(oldValue, newValue)
 	return nil
 }
 
@@ -198,16 +336,20 @@ func (c *Config) UpdateFromJson(content []byte) error {
 	return c.Update(updateMap)
 }
 
-// GetValue gets value by path
+// GetValue gets the raw value by a path like "a.b[2].c", see GetAs for typed access
 func (c *Config) GetValue(path string) (any, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return getNestedValue(c.rawMap, path)
+	return getNestedValue(c.rawMap, path, c.option.MatchMode)
 }
 
 // StartWatcher starts the file watcher
 func (c *Config) StartWatcher() error {
+	if len(c.sources) > 0 {
+		return c.startSources()
+	}
+
 	if c.watcher == nil {
 		return fmt.Errorf("no watcher configured")
 	}
@@ -221,6 +363,8 @@ func (c *Config) StartWatcher() error {
 
 // StopWatcher stops the file watcher
 func (c *Config) StopWatcher() error {
+	c.stopSources()
+
 	if c.watcher == nil {
 		return nil
 	}
@@ -230,6 +374,12 @@ func (c *Config) StopWatcher() error {
 
 // IsWatcherRunning returns whether the watcher is running
 func (c *Config) IsWatcherRunning() bool {
+	if len(c.sources) > 0 {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.cancel != nil
+	}
+
 	if c.watcher == nil {
 		return false
 	}
@@ -237,7 +387,8 @@ func (c *Config) IsWatcherRunning() bool {
 	return c.watcher.IsRunning()
 }
 
-// GetTarget returns the target struct pointer
+// GetTarget returns the target struct pointer, it is updated in place on
+// reload, use Snapshot or Current for race-free access
 func (c *Config) GetTarget() any {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
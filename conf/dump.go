@@ -0,0 +1,277 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secret values in dumps
+const redacted = "******"
+
+// dumpEntry is an effective value with its origin
+type dumpEntry struct {
+	Value  any    `json:"value"`
+	Origin string `json:"origin"`
+}
+
+// Dump returns the effective configuration in the given format, "yaml" or
+// "json", annotated with the origin of every value: the file and line, the
+// source, the environment variable or flag override, a runtime update, the
+// default tag, and the environment placeholders used. Fields tagged secret
+// and encrypted values are redacted.
+//
+// YAML dumps carry origins as line comments, JSON dumps wrap every value as
+// {"value": ..., "origin": ...}.
+func (c *Config) Dump(format string) ([]byte, error) {
+	c.mu.RLock()
+	rawMap, origins := c.rawMap, c.origins
+	c.mu.RUnlock()
+
+	snapshot := reflect.ValueOf(c.Snapshot()).Elem()
+	switch format {
+	case "yaml", "yml":
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		dumpStruct(snapshot, rawMap, origins, c.option, "", nil, func(path []string, value any, origin string) {
+			addYAMLEntry(node, path, value, origin)
+		})
+
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(node); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "json":
+		result := make(map[string]any)
+		dumpStruct(snapshot, rawMap, origins, c.option, "", nil, func(path []string, value any, origin string) {
+			setTreeValue(result, path, dumpEntry{Value: value, Origin: origin})
+		})
+		return json.MarshalIndent(result, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported dump format: %s", format)
+	}
+}
+
+// Values returns the effective configuration as nested maps keyed like the
+// config files, with durations and text types as strings. Secrets are
+// redacted like in Dump.
+func (c *Config) Values() map[string]any {
+	c.mu.RLock()
+	rawMap, origins := c.rawMap, c.origins
+	c.mu.RUnlock()
+
+	result := make(map[string]any)
+	snapshot := reflect.ValueOf(c.Snapshot()).Elem()
+	dumpStruct(snapshot, rawMap, origins, c.option, "", nil, func(path []string, value any, origin string) {
+		setTreeValue(result, path, value)
+	})
+	return result
+}
+
+// setTreeValue sets value at path in nested maps
+func setTreeValue(m map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[key] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+// dumpStruct walks struct v with its raw map and emits every leaf value with
+// its key path and origin
+func dumpStruct(v reflect.Value, rawMap map[string]any, origins Origins, option *Option, basePath string, keys []string, emit func(path []string, value any, origin string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldType.Tag.Get(option.TagName))
+		if tagInfo.Skip {
+			continue
+		}
+
+		field := v.Field(i)
+		if fieldType.Anonymous && derefType(fieldType.Type).Kind() == reflect.Struct {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+			dumpStruct(field, rawMap, origins, option, basePath, keys, emit)
+			continue
+		}
+
+		fieldName := fieldType.Name
+		if tagInfo.FieldName != "" {
+			fieldName = tagInfo.FieldName
+		}
+		key, exists := findKeyInMap(rawMap, fieldName, option.MatchMode)
+		if !exists {
+			key = keyNameOf(fieldType, tagInfo, option)
+		}
+		path := joinPath(basePath, key)
+		fieldKeys := append(keys[:len(keys):len(keys)], key)
+
+		if isNestedStruct(fieldType.Type) {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+			nested, _ := rawMap[key].(map[string]any)
+			dumpStruct(field, nested, origins, option, path, fieldKeys, emit)
+			continue
+		}
+
+		var origin string
+		rawValue, hasRaw := rawMap[key]
+		switch o, ok := origins.lookup(path, option.MatchMode); {
+		case ok && hasRaw:
+			origin = o.String()
+		case tagInfo.Default != "":
+			origin = Origin{Kind: OriginDefault}.String()
+		case hasRaw:
+			origin = Origin{Kind: OriginSource}.String()
+		default:
+			origin = Origin{Kind: OriginUnset}.String()
+		}
+		if s, ok := rawValue.(string); ok {
+			if placeholders := envVarRegex.FindAllString(s, -1); len(placeholders) > 0 {
+				origin += ", placeholder " + strings.Join(placeholders, " ")
+			}
+		}
+
+		var value any
+		if tagInfo.Secret || containsSecret(rawValue) || isFileReference(rawValue) {
+			value = redacted
+		} else {
+			value = plainValue(field, option)
+		}
+		emit(fieldKeys, value, origin)
+	}
+}
+
+// addYAMLEntry adds a leaf value with an origin comment to a YAML mapping node
+func addYAMLEntry(node *yaml.Node, path []string, value any, origin string) {
+	for _, key := range path[:len(path)-1] {
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, next)
+		}
+		node = next
+	}
+
+	valueNode := &yaml.Node{}
+	if err := valueNode.Encode(value); err != nil {
+		valueNode = &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprintf("%v", value)}
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: path[len(path)-1]}
+	if len(valueNode.Content) == 0 {
+		valueNode.Style = yaml.FlowStyle
+	}
+	if valueNode.Kind == yaml.ScalarNode || valueNode.Style == yaml.FlowStyle {
+		valueNode.LineComment = origin
+	} else {
+		keyNode.LineComment = origin
+	}
+	node.Content = append(node.Content, keyNode, valueNode)
+}
+
+// plainValue converts a field value to plain maps, slices and scalars for dumping,
+// struct keys follow the tag names and secret fields are redacted
+func plainValue(v reflect.Value, option *Option) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		return plainValue(v.Elem(), option)
+	}
+
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	if text, ok := textOf(v); ok {
+		return text
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		result := make(map[string]any)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			fieldType := t.Field(i)
+			if !fieldType.IsExported() {
+				continue
+			}
+			tagInfo := parseTag(fieldType.Tag.Get(option.TagName))
+			if tagInfo.Skip {
+				continue
+			}
+			if tagInfo.Secret {
+				result[keyNameOf(fieldType, tagInfo, option)] = redacted
+				continue
+			}
+			result[keyNameOf(fieldType, tagInfo, option)] = plainValue(v.Field(i), option)
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Array && !v.CanAddr() {
+				// Bytes needs an addressable array, copy it out
+				b := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), v.Len(), v.Len())
+				reflect.Copy(b, v)
+				v = b
+			}
+			return string(v.Bytes())
+		}
+		result := make([]any, v.Len())
+		for i := range result {
+			result[i] = plainValue(v.Index(i), option)
+		}
+		return result
+	case reflect.Map:
+		result := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			result[fmt.Sprintf("%v", iter.Key().Interface())] = plainValue(iter.Value(), option)
+		}
+		return result
+	default:
+		return v.Interface()
+	}
+}
+
+// isFileReference reports whether value reads a ${file:...} reference, they
+// usually point at mounted secrets
+func isFileReference(value any) bool {
+	s, ok := value.(string)
+	return ok && strings.Contains(s, "${file:")
+}
@@ -3,7 +3,9 @@ package conf
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
@@ -27,7 +29,12 @@ var (
 	envVarRegex = regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
 )
 
-// processEnvVars processes environment variables in a string value
+// processEnvVars processes environment variables in a string value.
+//
+// Supported references:
+//   - ${VAR} and ${VAR:default}: environment variable, with an optional default
+//   - ${env:VAR} and ${env:VAR:default}: the same, spelled explicitly
+//   - ${file:/path}: content of the file, trailing newlines trimmed
 func processEnvVars(value string, useEnv bool) (string, error) {
 	if !useEnv {
 		// Fast check for env var syntax
@@ -45,44 +52,95 @@ func processEnvVars(value string, useEnv bool) (string, error) {
 		return value, nil
 	}
 
-	// First, check for any environment variables without default values that don't exist
-	matches := envVarRegex.FindAllStringSubmatch(value, -1)
-	for _, match := range matches {
-		if len(match) >= 2 {
-			envVar := match[1]
-			envValue := os.Getenv(envVar)
-
-			// Check if there's a default value (match[2] exists and is not empty or colon is present)
-			hasDefault := len(match) > 2 && strings.Contains(match[0], ":")
-
-			// If env var doesn't exist and no default value provided
-			if envValue == "" && !hasDefault {
-				return "", fmt.Errorf("environment variable %s not found", envVar)
-			}
-		}
-	}
-
+	var firstErr error
 	result := envVarRegex.ReplaceAllStringFunc(value, func(match string) string {
 		submatches := envVarRegex.FindStringSubmatch(match)
 		if len(submatches) < 2 {
 			return match
 		}
 
-		envVar := submatches[1]
-		defaultValue := ""
-		if len(submatches) > 2 {
-			defaultValue = submatches[2]
+		// The colon is present when a default or reference argument is given
+		hasArg := strings.Contains(match, ":")
+		resolved, err := resolveReference(submatches[1], submatches[2], hasArg)
+		if err != nil && firstErr == nil {
+			firstErr = err
 		}
+		return resolved
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+
+	return result, nil
+}
 
-		envValue := os.Getenv(envVar)
-		if envValue != "" {
-			return envValue
+// resolveReference resolves a ${name:arg} reference
+func resolveReference(name, arg string, hasArg bool) (string, error) {
+	switch name {
+	case "file":
+		if arg == "" {
+			return "", fmt.Errorf("file reference without path")
+		}
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return "", fmt.Errorf("failed to read referenced file %s: %w", arg, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case "env":
+		envVar, defaultValue, hasDefault := strings.Cut(arg, ":")
+		if envVar == "" {
+			return "", fmt.Errorf("env reference without variable name")
 		}
+		return lookupEnvVar(envVar, defaultValue, hasDefault)
+	default:
+		return lookupEnvVar(name, arg, hasArg)
+	}
+}
 
-		return defaultValue
-	})
+// lookupEnvVar returns the value of environment variable name, or defaultValue
+// when it is empty, an error is returned when neither exists
+func lookupEnvVar(name, defaultValue string, hasDefault bool) (string, error) {
+	if envValue := os.Getenv(name); envValue != "" {
+		return envValue, nil
+	}
+	if !hasDefault {
+		return "", fmt.Errorf("environment variable %s not found", name)
+	}
+	return defaultValue, nil
+}
 
-	return result, nil
+// CheckReferences resolves every ${...} placeholder in the raw configuration,
+// including keys not mapped to fields, and reports the unresolvable ones
+func (c *Config) CheckReferences() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var errs ValidationErrors
+	checkReferences(c.rawMap, "", &errs)
+	return errs.err()
+}
+
+// checkReferences resolves the placeholders in value below path
+func checkReferences(value any, path string, errs *ValidationErrors) {
+	switch v := value.(type) {
+	case string:
+		if _, err := processEnvVars(v, true); err != nil {
+			*errs = errs.append(newFieldError(path, "reference", "%v", err))
+		}
+	case []any:
+		for i, item := range v {
+			checkReferences(item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			checkReferences(v[key], joinPath(path, key), errs)
+		}
+	}
 }
 
 // processEnvValue processes environment variables in any value
@@ -94,3 +152,54 @@ func processEnvValue(value any, useEnv bool) (any, error) {
 		return value, nil
 	}
 }
+
+// applyEnvOverrides overrides rawMap values with environment variables
+// derived from the tag paths of target type t and records their origins
+func applyEnvOverrides(rawMap map[string]any, origins Origins, t reflect.Type, option *Option) {
+	if !option.AutoEnv {
+		return
+	}
+
+	walkFields(t, option, nil, func(path []string, field reflect.StructField, tagInfo *TagInfo) {
+		envName := tagInfo.Env
+		if envName == "" {
+			envName = envNameOf(path, option)
+		}
+		envValue, ok := os.LookupEnv(envName)
+		if !ok {
+			return
+		}
+
+		var value any = envValue
+		if isListType(field.Type) {
+			value = splitList(envValue)
+		}
+		origins[setPathValue(rawMap, path, value, option.MatchMode)] = Origin{Kind: OriginEnv, Name: envName}
+	})
+}
+
+// envNameOf derives the environment variable name of a field path
+func envNameOf(path []string, option *Option) string {
+	parts := make([]string, 0, len(path)+1)
+	if option.EnvPrefix != "" {
+		parts = append(parts, option.EnvPrefix)
+	}
+	for _, part := range path {
+		parts = append(parts, strings.ToUpper(toSnakeCase(part)))
+	}
+	return strings.Join(parts, option.EnvSeparator)
+}
+
+// splitList splits a comma separated list
+func splitList(value string) []any {
+	if strings.TrimSpace(value) == "" {
+		return []any{}
+	}
+
+	items := strings.Split(value, ",")
+	result := make([]any, 0, len(items))
+	for _, item := range items {
+		result = append(result, strings.TrimSpace(item))
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+package conf
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+)
+
+// flagValue is a flag.Value holding the raw string of a config field
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+// String implements flag.Value
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+// Set implements flag.Value
+func (f *flagValue) Set(s string) error {
+	f.value = s
+	return nil
+}
+
+// IsBoolFlag allows bool fields to be set as `-name` without a value
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// BindFlags registers a flag on fs for every field of T.
+//
+// Flag names are derived from tag paths, e.g. `database.max-conns` for
+// Database.MaxConns, help text comes from the usage= tag part and defaults
+// from the default= tag part. Pass the parsed fs to Load with WithFlagSet,
+// only flags set on the command line override the config, with precedence
+// file < env < flags. Slices are set from comma separated lists.
+func BindFlags[T any](fs *flag.FlagSet, opts ...func(*Option)) {
+	option := NewOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	var target *T
+	walkFields(reflect.TypeOf(target), option, nil, func(path []string, field reflect.StructField, tagInfo *TagInfo) {
+		name := flagNameOf(path)
+		if fs.Lookup(name) != nil {
+			return
+		}
+
+		usage := tagInfo.Usage
+		if usage == "" {
+			usage = strings.Join(path, ".")
+		}
+		if len(tagInfo.Options) > 0 {
+			usage += " (" + strings.Join(tagInfo.Options, "|") + ")"
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		fs.Var(&flagValue{value: tagInfo.Default, isBool: ft.Kind() == reflect.Bool}, name, usage)
+	})
+}
+
+// applyFlagOverrides overrides rawMap values with the flags set on the command
+// line and records their origins
+func applyFlagOverrides(rawMap map[string]any, origins Origins, t reflect.Type, option *Option) {
+	if option.FlagSet == nil {
+		return
+	}
+
+	visited := make(map[string]string)
+	option.FlagSet.Visit(func(f *flag.Flag) {
+		visited[f.Name] = f.Value.String()
+	})
+	if len(visited) == 0 {
+		return
+	}
+
+	walkFields(t, option, nil, func(path []string, field reflect.StructField, tagInfo *TagInfo) {
+		name := flagNameOf(path)
+		flagValue, ok := visited[name]
+		if !ok {
+			return
+		}
+
+		var value any = flagValue
+		if isListType(field.Type) {
+			value = splitList(flagValue)
+		}
+		origins[setPathValue(rawMap, path, value, option.MatchMode)] = Origin{Kind: OriginFlag, Name: "-" + name}
+	})
+}
+
+// flagNameOf derives the flag name of a field path
+func flagNameOf(path []string) string {
+	parts := make([]string, len(path))
+	for i, part := range path {
+		parts[i] = strings.ReplaceAll(toSnakeCase(part), "_", "-")
+	}
+	return strings.Join(parts, ".")
+}
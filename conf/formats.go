@@ -0,0 +1,249 @@
+package conf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/joho/godotenv"
+)
+
+// parseINI parses INI data and returns rawMap. Sections and dotted keys
+// become nested maps, e.g. "host" in section [database.primary] is read
+// as database.primary.host. Lines starting with ';' or '#' are comments.
+func parseINI(data []byte) (map[string]any, error) {
+	rawMap := make(map[string]any)
+	section := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("failed to parse INI: line %d: unterminated section header", lineNo)
+			}
+			section = strings.TrimSpace(line[1:end])
+			if section != "" {
+				if err := setDottedKey(rawMap, section, make(map[string]any)); err != nil {
+					return nil, fmt.Errorf("failed to parse INI: line %d: %w", lineNo, err)
+				}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			key, value, ok = strings.Cut(line, ":")
+		}
+		if !ok {
+			return nil, fmt.Errorf("failed to parse INI: line %d: expect key = value", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("failed to parse INI: line %d: empty key", lineNo)
+		}
+
+		if err := setDottedKey(rawMap, joinPath(section, key), iniValue(value)); err != nil {
+			return nil, fmt.Errorf("failed to parse INI: line %d: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse INI: %w", err)
+	}
+	return rawMap, nil
+}
+
+// iniValue returns the value of an INI entry, quoted values are unquoted and
+// unquoted values lose their inline comments
+func iniValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		if value[0] == '"' {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				return unquoted
+			}
+		}
+		return value[1 : len(value)-1]
+	}
+	for _, marker := range []string{" ;", " #", "\t;", "\t#"} {
+		if i := strings.Index(value, marker); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
+
+// parseProperties parses Java properties data and returns rawMap. Dotted
+// keys become nested maps, e.g. database.host=localhost is read as
+// database.host. Supports '=', ':' and whitespace separators, '#' and '!'
+// comments, line continuations and escape sequences.
+func parseProperties(data []byte) (map[string]any, error) {
+	rawMap := make(map[string]any)
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if i == 0 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// Join continuation lines, a line ending with an odd number of
+		// backslashes continues on the next line
+		for endsWithContinuation(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+
+		rawKey, rawValue := splitProperty(line)
+		key, err := unescapeProperty(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse properties: line %d: %w", lineNo, err)
+		}
+		value, err := unescapeProperty(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse properties: line %d: %w", lineNo, err)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("failed to parse properties: line %d: empty key", lineNo)
+		}
+
+		if err := setDottedKey(rawMap, key, value); err != nil {
+			return nil, fmt.Errorf("failed to parse properties: line %d: %w", lineNo, err)
+		}
+	}
+	return rawMap, nil
+}
+
+// endsWithContinuation reports whether a properties line ends with an
+// unescaped backslash
+func endsWithContinuation(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// splitProperty splits a properties line into its raw key and value
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = rest[1:]
+			}
+			return line[:i], strings.TrimLeft(rest, " \t\f")
+		}
+	}
+	return line, ""
+}
+
+// unescapeProperty resolves the escape sequences of a properties key or value
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("malformed \\uXXXX escape")
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uXXXX escape: %w", err)
+			}
+			b.WriteRune(rune(code))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	if !utf8.ValidString(b.String()) {
+		return "", fmt.Errorf("invalid UTF-8 after unescaping")
+	}
+	return b.String(), nil
+}
+
+// parseDotenv parses dotenv data and returns rawMap, dotted keys become
+// nested maps while other keys like DB_HOST stay flat
+func parseDotenv(data []byte) (map[string]any, error) {
+	env, err := godotenv.UnmarshalBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dotenv: %w", err)
+	}
+
+	rawMap := make(map[string]any, len(env))
+	for key, value := range env {
+		if err := setDottedKey(rawMap, key, value); err != nil {
+			return nil, fmt.Errorf("failed to parse dotenv: %w", err)
+		}
+	}
+	return rawMap, nil
+}
+
+// setDottedKey sets value at the dotted key path in m, creating nested maps.
+// Setting a map on an existing map keeps the existing entries.
+func setDottedKey(m map[string]any, key string, value any) error {
+	parts := strings.Split(key, ".")
+	current := m
+	for i, part := range parts[:len(parts)-1] {
+		switch next := current[part].(type) {
+		case map[string]any:
+			current = next
+		case nil:
+			nested := make(map[string]any)
+			current[part] = nested
+			current = nested
+		default:
+			return fmt.Errorf("key %s conflicts with value of %s", key, strings.Join(parts[:i+1], "."))
+		}
+	}
+
+	last := parts[len(parts)-1]
+	if existing, ok := current[last].(map[string]any); ok {
+		if _, isMap := value.(map[string]any); isMap {
+			return nil
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("key %s conflicts with nested keys", key)
+		}
+	}
+	current[last] = value
+	return nil
+}
@@ -0,0 +1,433 @@
+package conf
+
+import (
+	"bytes"
+	"fmt"
+	goformat "go/format"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/meta-apex/gopkg/cast"
+)
+
+// byteSizeRegex matches sample strings generated as ByteSize
+var byteSizeRegex = regexp.MustCompile(`(?i)^\d+(\.\d+)?\s*(k|m|g|t|p)i?b?$`)
+
+// commonInitialisms are kept upper case in generated field names
+var commonInitialisms = map[string]bool{
+	"API": true, "CPU": true, "DB": true, "DNS": true, "DSN": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "RPC": true, "SQL": true, "SSL": true,
+	"TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "URI": true,
+	"URL": true, "UUID": true, "XML": true,
+}
+
+// genStruct is a generated struct type
+type genStruct struct {
+	name   string
+	fields []genField
+}
+
+// genField is a field of a generated struct
+type genField struct {
+	name   string
+	goType string
+	tag    string
+}
+
+// generator generates struct types from a sample document
+type generator struct {
+	option  *Option
+	order   map[string][]string // Key order of the sample by path
+	structs []*genStruct
+	names   map[string]bool
+	imports map[string]bool
+}
+
+// GenerateStructs generates Go struct types with meta tags from a sample
+// config document in format, "json", "yaml" or "toml". The root type is
+// named typeName in package pkg.
+//
+// Field types are inferred from the sample values: nested objects become
+// struct types, lists become slices, strings like "30s" become
+// time.Duration and strings like "512MB" become conf.ByteSize. Scalar sample
+// values become defaults, null and empty values become optional fields.
+// Tag names are only written when the key is not matched from the field
+// name under Option.MatchMode.
+func GenerateStructs(data []byte, format, pkg, typeName string, opts ...func(*Option)) ([]byte, error) {
+	option := NewOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	sample, err := parseConfigData(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		option:  option,
+		order:   make(map[string][]string),
+		names:   make(map[string]bool),
+		imports: make(map[string]bool),
+	}
+	if format == "json" || format == "yaml" || format == "yml" {
+		// JSON is YAML, the node tree keeps the key order of the sample
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err == nil {
+			keyOrder(&node, "", g.order)
+		}
+	}
+	g.structType(typeName, []map[string]any{sample}, "", false)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated from a sample config, edit as needed.\n\npackage %s\n\n", pkg)
+	if len(g.imports) > 0 {
+		// Standard library imports first, then the others
+		var std, others []string
+		for imp := range g.imports {
+			if strings.Contains(imp, ".") {
+				others = append(others, strconv.Quote(imp))
+			} else {
+				std = append(std, strconv.Quote(imp))
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(others)
+		groups := []string{strings.Join(std, "\n"), strings.Join(others, "\n")}
+		fmt.Fprintf(&buf, "import (\n%s\n)\n\n", strings.TrimSpace(strings.Join(groups, "\n\n")))
+	}
+	for _, s := range g.structs {
+		fmt.Fprintf(&buf, "type %s struct {\n", s.name)
+		for _, f := range s.fields {
+			if f.tag == "" {
+				fmt.Fprintf(&buf, "%s %s\n", f.name, f.goType)
+				continue
+			}
+			fmt.Fprintf(&buf, "%s %s `%s:%s`\n", f.name, f.goType, option.TagName, strconv.Quote(f.tag))
+		}
+		buf.WriteString("}\n\n")
+	}
+
+	return goformat.Source(buf.Bytes())
+}
+
+// structType generates a struct type named name from sample objects and
+// returns its unique name, values of list elements are not used as defaults
+func (g *generator) structType(name string, samples []map[string]any, path string, inList bool) string {
+	name = g.uniqueName(name)
+	s := &genStruct{name: name}
+	g.structs = append(g.structs, s)
+
+	// Keys of every sample, in sample order
+	var keys []string
+	seen := make(map[string]bool)
+	for _, sample := range samples {
+		for _, key := range g.keysOf(sample, path) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	fieldNames := make(map[string]bool)
+	for _, key := range keys {
+		var values []any
+		missing := false
+		for _, sample := range samples {
+			value, exists := sample[key]
+			if !exists {
+				missing = true
+				continue
+			}
+			values = append(values, value)
+		}
+
+		fieldName := goFieldName(key)
+		for i := 2; fieldNames[fieldName]; i++ {
+			fieldName = goFieldName(key) + strconv.Itoa(i)
+		}
+		fieldNames[fieldName] = true
+
+		goType, defaultValue, optional := g.fieldType(fieldName, values, joinPath(path, key), inList)
+		s.fields = append(s.fields, genField{
+			name:   fieldName,
+			goType: goType,
+			tag:    g.tag(fieldName, key, defaultValue, optional || missing),
+		})
+	}
+	return name
+}
+
+// fieldType infers the Go type of a field from its sample values, with the
+// default value and whether the field is optional
+func (g *generator) fieldType(fieldName string, values []any, path string, inList bool) (string, string, bool) {
+	var present []any
+	for _, value := range values {
+		if value != nil {
+			present = append(present, value)
+		}
+	}
+	optional := len(present) < len(values) || len(present) == 0
+	if len(present) == 0 {
+		return "any", "", true
+	}
+
+	switch first := present[0].(type) {
+	case map[string]any:
+		var samples []map[string]any
+		for _, value := range present {
+			m, ok := value.(map[string]any)
+			if !ok {
+				return "any", "", optional
+			}
+			samples = append(samples, m)
+		}
+		if len(first) == 0 && len(samples) == 1 {
+			return "map[string]any", "", true
+		}
+		return g.structType(fieldName, samples, path, inList), "", optional
+	case []any:
+		var items []any
+		for _, value := range present {
+			list, ok := value.([]any)
+			if !ok {
+				return "any", "", optional
+			}
+			items = append(items, list...)
+		}
+		if len(items) == 0 {
+			return "[]any", "", true
+		}
+		elemType, _, _ := g.fieldType(singular(fieldName), items, path, true)
+		return "[]" + elemType, "", optional
+	}
+
+	goType, defaultValue := g.scalarType(present)
+	if goType == "string" && present[0] == "" {
+		optional = true
+	}
+	if inList || len(present) > 1 {
+		// Values of list elements are not defaults
+		defaultValue = ""
+	}
+	return goType, defaultValue, optional
+}
+
+// scalarType infers the Go type of scalar sample values and their default
+func (g *generator) scalarType(values []any) (string, string) {
+	goType := ""
+	for _, value := range values {
+		t := scalarTypeOf(value)
+		switch {
+		case goType == "" || goType == t:
+			goType = t
+		case isNumberType(goType) && isNumberType(t):
+			goType = "float64"
+		default:
+			return "any", ""
+		}
+	}
+
+	switch goType {
+	case "time.Duration", "time.Time":
+		g.imports["time"] = true
+	case "conf.ByteSize":
+		g.imports["github.com/meta-apex/gopkg/conf"] = true
+	}
+	return goType, defaultText(values[0])
+}
+
+// scalarTypeOf infers the Go type of a scalar sample value
+func scalarTypeOf(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return "bool"
+	case int, int64, uint64:
+		return "int"
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return "int"
+		}
+		return "float64"
+	case time.Time:
+		return "time.Time"
+	case string:
+		if _, err := cast.ParseDuration(v); err == nil && strings.ContainsAny(v, "hmsµundw") {
+			return "time.Duration"
+		}
+		if byteSizeRegex.MatchString(v) {
+			return "conf.ByteSize"
+		}
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			return "time.Time"
+		}
+		return "string"
+	default:
+		return "any"
+	}
+}
+
+// isNumberType reports whether t is a generated number type
+func isNumberType(t string) bool {
+	return t == "int" || t == "float64"
+}
+
+// defaultText formats a sample value as a default tag part, values with commas
+// are quoted and values the tag syntax cannot hold are left out
+func defaultText(value any) string {
+	var s string
+	switch v := value.(type) {
+	case time.Time:
+		s = v.Format(time.RFC3339)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+	switch {
+	case strings.Contains(s, "`"):
+		// Generated tags are raw string literals
+		return ""
+	case strings.Contains(s, ",") || strings.HasPrefix(s, "'"):
+		// A quoted value ends at a quote followed by a comma or the end of the tag
+		if strings.Contains(s, "',") || strings.HasSuffix(s, "'") {
+			return ""
+		}
+		return "'" + s + "'"
+	}
+	return s
+}
+
+// tag returns the meta tag of a field
+func (g *generator) tag(fieldName, key, defaultValue string, optional bool) string {
+	parts := []string{key}
+	if _, matched := findKeyInMap(map[string]any{key: nil}, fieldName, g.option.MatchMode); matched {
+		parts[0] = ""
+	}
+	if defaultValue != "" {
+		parts = append(parts, "default="+defaultValue)
+	}
+	if optional {
+		parts = append(parts, "optional")
+	}
+	if len(parts) == 1 && parts[0] == "" {
+		return ""
+	}
+	return strings.Join(parts, ",")
+}
+
+// keysOf returns the keys of a sample object in sample order
+func (g *generator) keysOf(m map[string]any, path string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range g.order[path] {
+		if _, exists := m[key]; exists && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	var rest []string
+	for key := range m {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// uniqueName returns name, suffixed with a number if already taken
+func (g *generator) uniqueName(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	g.names[unique] = true
+	return unique
+}
+
+// keyOrder collects the key order of the mappings of a YAML node tree by
+// path, keys of list elements share the path of the list
+func keyOrder(node *yaml.Node, path string, order map[string][]string) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			keyOrder(child, path, order)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			order[path] = append(order[path], key)
+			keyOrder(node.Content[i+1], joinPath(path, key), order)
+		}
+	}
+}
+
+// goFieldName converts a config key like "max_conns" or "maxConns" to an
+// exported Go field name like "MaxConns"
+func goFieldName(key string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		upper := strings.ToUpper(w)
+		if commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		rs := []rune(strings.ToLower(w))
+		rs[0] = unicode.ToUpper(rs[0])
+		b.WriteString(string(rs))
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// singular returns the element type name of a list field name, e.g. Servers -> Server
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ses") || strings.HasSuffix(name, "xes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && len(name) > 1:
+		return name[:len(name)-1]
+	default:
+		return name + "Item"
+	}
+}
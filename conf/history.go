@@ -0,0 +1,154 @@
+package conf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Version describes an applied configuration version
+type Version struct {
+	Version uint64    // Sequence number, 1 for the initial load
+	Time    time.Time // When the version was applied
+	Source  string    // What applied the version: load, reload, update or rollback
+	Hash    string    // SHA-256 of the raw configuration
+	Changes []string  // Paths changed from the previous version, prefixed with +, - or ~
+
+	RollbackOf uint64 // Version rolled back to when Source is rollback
+}
+
+// historyEntry is a version with the raw configuration to roll back to
+type historyEntry struct {
+	Version
+	rawMap  map[string]any
+	origins Origins
+}
+
+// History returns the applied versions kept in the history, oldest first.
+// The size of the history is bounded by Option.HistorySize.
+func (c *Config) History() []Version {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]Version, len(c.history))
+	for i, entry := range c.history {
+		result[i] = entry.Version
+	}
+	return result
+}
+
+// Rollback re-applies the configuration of a previous version through the
+// normal update path, the rollback is validated, recorded as a new version
+// and delivered to subscribers. Persisted files are left unchanged.
+func (c *Config) Rollback(version uint64) error {
+	if !c.option.Updatable {
+		return fmt.Errorf("config is not updatable")
+	}
+
+	c.mu.Lock()
+	var target *historyEntry
+	for i := range c.history {
+		if c.history[i].Version.Version == version {
+			target = &c.history[i]
+			break
+		}
+	}
+	if target == nil {
+		c.mu.Unlock()
+		return fmt.Errorf("config version %d not found in history", version)
+	}
+	rawMap := cloneValue(target.rawMap).(map[string]any)
+	oldValue, newValue, err := c.commit(rawMap, target.origins.clone(), Version{Source: "rollback", RollbackOf: version})
+	c.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to roll back to version %d: %w", version, err)
+	}
+
+	c.publish(oldValue, newValue)
+	return nil
+}
+
+// record adds rawMap to the history as a new version described by applied
+// unless it is identical to the latest one, the caller must hold c.mu
+func (c *Config) record(applied Version, rawMap map[string]any, origins Origins) {
+	if c.option.HistorySize <= 0 {
+		return
+	}
+
+	hash := hashMap(rawMap)
+	var previous map[string]any
+	if n := len(c.history); n > 0 {
+		if c.history[n-1].Hash == hash {
+			return
+		}
+		previous = c.history[n-1].rawMap
+	}
+
+	c.nextVersion++
+	applied.Version = c.nextVersion
+	applied.Time = time.Now()
+	applied.Hash = hash
+	applied.Changes = diffPaths(previous, rawMap)
+	c.history = append(c.history, historyEntry{
+		Version: applied,
+		rawMap:  rawMap,
+		origins: origins,
+	})
+	if extra := len(c.history) - c.option.HistorySize; extra > 0 {
+		c.history = append(c.history[:0:0], c.history[extra:]...)
+	}
+}
+
+// hashMap returns the SHA-256 of the canonical JSON encoding of m
+func hashMap(m map[string]any) string {
+	// Map keys are sorted by encoding/json
+	data, err := json.Marshal(m)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%v", m))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// diffPaths lists the leaf paths added, removed or changed from old to new
+func diffPaths(old, new map[string]any) []string {
+	oldLeaves, newLeaves := make(map[string]any), make(map[string]any)
+	leafValues(old, "", oldLeaves)
+	leafValues(new, "", newLeaves)
+
+	var changes []string
+	for path, value := range newLeaves {
+		oldValue, exists := oldLeaves[path]
+		switch {
+		case !exists:
+			changes = append(changes, "+ "+path)
+		case !reflect.DeepEqual(oldValue, value):
+			changes = append(changes, "~ "+path)
+		}
+	}
+	for path := range oldLeaves {
+		if _, exists := newLeaves[path]; !exists {
+			changes = append(changes, "- "+path)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i][2:] < changes[j][2:]
+	})
+	return changes
+}
+
+// leafValues collects the leaf values of nested maps by dotted path
+func leafValues(value any, path string, result map[string]any) {
+	if m, ok := value.(map[string]any); ok && (len(m) > 0 || path == "") {
+		for key, item := range m {
+			leafValues(item, joinPath(path, key), result)
+		}
+		return
+	}
+	result[path] = value
+}
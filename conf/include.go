@@ -0,0 +1,122 @@
+package conf
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IncludeKey is the key of include directives in config files. Its value is a
+// file path or a list of file paths, relative paths are resolved against the
+// including file. The included maps are merged at the node holding the
+// directive, keys next to the directive override the included values.
+const IncludeKey = "$include"
+
+// parseFileWithIncludes parses filename and resolves its include directives,
+// it returns the raw map, the origins of its values and the included files
+func parseFileWithIncludes(filename string, option *Option, stack []string) (map[string]any, Origins, []string, error) {
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i, file := range stack {
+		if file == absPath {
+			cycle := append(stack[i:len(stack):len(stack)], absPath)
+			return nil, nil, nil, fmt.Errorf("include cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	stack = append(stack[:len(stack):len(stack)], absPath)
+
+	rawMap, lines, err := parseConfigFileWithLines(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	origins := make(Origins)
+	origins.setLeaves("", rawMap, Origin{Kind: OriginFile, Name: filename}, lines)
+
+	var included []string
+	if err := resolveIncludes(rawMap, "", filepath.Dir(filename), option, stack, origins, &included); err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return rawMap, origins, included, nil
+}
+
+// resolveIncludes replaces the include directives in m and its nested maps
+// with the content of the included files
+func resolveIncludes(m map[string]any, path, dir string, option *Option, stack []string, origins Origins, included *[]string) error {
+	if value, exists := m[IncludeKey]; exists {
+		files, err := includeFiles(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", joinPath(path, IncludeKey), err)
+		}
+		delete(m, IncludeKey)
+		delete(origins, joinPath(path, IncludeKey))
+
+		merged := make(map[string]any)
+		mergedOrigins := make(Origins)
+		for _, file := range files {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			rawMap, fileOrigins, nested, err := parseFileWithIncludes(file, option, stack)
+			if err != nil {
+				return err
+			}
+			mergeMaps(merged, rawMap, option)
+			for p, origin := range fileOrigins {
+				mergedOrigins[joinPath(path, p)] = origin
+			}
+			*included = append(*included, file)
+			*included = append(*included, nested...)
+		}
+
+		// Local keys override the included values
+		mergeMaps(merged, m, option)
+		for key := range m {
+			delete(m, key)
+		}
+		for key, value := range merged {
+			m[key] = value
+		}
+		for p, origin := range mergedOrigins {
+			if _, exists := origins[p]; !exists {
+				origins[p] = origin
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if nested, ok := m[key].(map[string]any); ok {
+			if err := resolveIncludes(nested, joinPath(path, key), dir, option, stack, origins, included); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// includeFiles returns the file paths of an include directive value
+func includeFiles(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		files := make([]string, 0, len(v))
+		for i, item := range v {
+			file, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("[%d]: expect a file path, got %T", i, item)
+			}
+			files = append(files, file)
+		}
+		return files, nil
+	default:
+		return nil, fmt.Errorf("expect a file path or a list of file paths, got %T", value)
+	}
+}
@@ -0,0 +1,188 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MustLoadLayers loads configuration from layered files, panics on error
+func MustLoadLayers[T any](files []string, opts ...func(*Option)) *T {
+	result, err := LoadLayers[T](files, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// LoadLayers loads configuration from multiple files deep-merged in order.
+//
+// Each file may be followed by an optional profile overlay named
+// `name.<profile>.ext`, e.g. config.yaml is followed by config.prod.yaml
+// when the profile is "prod". The profile is taken from Option.Profile,
+// or from the environment variable named by Option.ProfileEnv.
+//
+// Merge semantics: maps are merged recursively, scalars are replaced,
+// slices are replaced or appended according to Option.SliceMerge, and a
+// null value in an overlay removes the key.
+func LoadLayers[T any](files []string, opts ...func(*Option)) (*T, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config files given")
+	}
+
+	config, err := New[T](func(v *Config) error {
+		v.files = resolveLayers(files, v.profile())
+		rawMap, origins, err := v.loadFiles()
+		if err != nil {
+			return err
+		}
+		v.rawMap = rawMap
+		v.origins = origins
+		v.file = files[0]
+		return nil
+	}, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config.target.(*T), nil
+}
+
+// layer represents a config file in the merge order
+type layer struct {
+	file     string
+	optional bool
+}
+
+// profile returns the active profile name
+func (c *Config) profile() string {
+	if c.option.Profile != "" {
+		return c.option.Profile
+	}
+	if c.option.ProfileEnv != "" {
+		return os.Getenv(c.option.ProfileEnv)
+	}
+	return ""
+}
+
+// resolveLayers expands files with their profile overlays
+func resolveLayers(files []string, profile string) []layer {
+	layers := make([]layer, 0, len(files)*2)
+	for _, file := range files {
+		layers = append(layers, layer{file: file})
+		if profile != "" {
+			layers = append(layers, layer{file: profileFile(file, profile), optional: true})
+		}
+	}
+	return layers
+}
+
+// profileFile returns the overlay file name of file for profile
+func profileFile(file, profile string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "." + profile + ext
+}
+
+// loadFiles parses all layers with their includes and merges them in order
+func (c *Config) loadFiles() (map[string]any, Origins, error) {
+	result := make(map[string]any)
+	origins := make(Origins)
+	var included []string
+	for _, l := range c.files {
+		// Only a missing overlay itself is skipped, errors of its includes are not
+		if l.optional {
+			if _, err := os.Stat(l.file); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+		}
+		rawMap, fileOrigins, files, err := parseFileWithIncludes(l.file, c.option, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		mergeMaps(result, rawMap, c.option)
+		origins.merge(fileOrigins)
+		included = append(included, files...)
+	}
+
+	c.mu.Lock()
+	c.included = included
+	c.mu.Unlock()
+	return result, origins, nil
+}
+
+// includedFiles returns the files included by the layers of c
+func (c *Config) includedFiles() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.included
+}
+
+// mergeMaps deep-merges src into dst
+func mergeMaps(dst, src map[string]any, option *Option) {
+	for key, srcValue := range src {
+		dstKey := matchKey(dst, key, option.MatchMode)
+
+		if srcValue == nil {
+			delete(dst, dstKey)
+			continue
+		}
+
+		dstValue, exists := dst[dstKey]
+		if !exists {
+			dst[key] = cloneValue(srcValue)
+			continue
+		}
+
+		switch sv := srcValue.(type) {
+		case map[string]any:
+			if dv, ok := dstValue.(map[string]any); ok {
+				mergeMaps(dv, sv, option)
+				continue
+			}
+		case []any:
+			if dv, ok := dstValue.([]any); ok && option.SliceMerge == SliceAppend {
+				dst[dstKey] = append(dv, cloneValue(sv).([]any)...)
+				continue
+			}
+		}
+		dst[dstKey] = cloneValue(srcValue)
+	}
+}
+
+// matchKey returns the key in m matching key according to mode, or key itself
+func matchKey(m map[string]any, key string, mode MatchMode) string {
+	if _, exists := m[key]; exists {
+		return key
+	}
+	if mode == MatchIgnoreCase {
+		for k := range m {
+			if strings.EqualFold(k, key) {
+				return k
+			}
+		}
+	}
+	return key
+}
+
+// cloneValue deep copies maps and slices of a raw value
+func cloneValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = cloneValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = cloneValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
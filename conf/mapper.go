@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strconv"
 	"time"
+
+	"github.com/meta-apex/gopkg/cast"
 )
 
 // mapToStruct maps rawMap to struct using reflection
@@ -12,7 +14,8 @@ func mapToStruct(rawMap map[string]any, target any, option *Option, isUpdate boo
 	return mapToStructWithPath(rawMap, target, option, "", isUpdate, false)
 }
 
-// mapToStructWithPath maps rawMap to struct with field path tracking
+// mapToStructWithPath maps rawMap to struct with field path tracking, field
+// errors are collected so that every violation is reported at once
 func mapToStructWithPath(rawMap map[string]any, target any, option *Option, basePath string, isUpdate bool, parentOptional bool) error {
 	v := reflect.ValueOf(target)
 	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
@@ -22,6 +25,7 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 	v = v.Elem()
 	t := v.Type()
 
+	var errs ValidationErrors
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Field(i)
 		fieldType := t.Field(i)
@@ -52,13 +56,16 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 			fieldPath = basePath + "." + fieldName
 		}
 
+		if tagInfo.Err != nil && option.StrictTags {
+			errs = errs.append(fmt.Errorf("field %s tag error: %w", fieldPath, tagInfo.Err))
+			continue
+		}
+
 		// Handle anonymous struct (embedded)
 		if fieldType.Anonymous {
 			if field.Kind() == reflect.Struct {
 				// Use current rawMap for anonymous struct
-				if err := mapToStructWithPath(rawMap, field.Addr().Interface(), option, basePath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
-					return err
-				}
+				errs = errs.append(mapToStructWithPath(rawMap, field.Addr().Interface(), option, basePath, isUpdate, parentOptional || tagInfo.Optional))
 			}
 			continue
 		}
@@ -71,11 +78,12 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 				continue
 			}
 
-			// Handle struct fields
-			if field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct) {
-				if err := handleStructField(field, fieldType, option, fieldPath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
-					return err
+			// Handle struct fields, optional pointers to struct stay nil
+			if isNestedStruct(field.Type()) {
+				if field.Kind() == reflect.Ptr && (tagInfo.Optional || parentOptional) {
+					continue
 				}
+				errs = errs.append(handleStructField(field, fieldType, option, fieldPath, isUpdate, parentOptional || tagInfo.Optional))
 				continue
 			}
 
@@ -83,14 +91,15 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 			if tagInfo.Default != "" {
 				processedDefault, err := processEnvVars(tagInfo.Default, option.UseEnv)
 				if err != nil {
-					return fmt.Errorf("field %s default value error: %w", fieldPath, err)
+					errs = errs.append(fmt.Errorf("field %s default value error: %w", fieldPath, err))
+					continue
 				}
 				value = processedDefault
 				exists = true
 			} else {
 				// Check if field is required
 				if !tagInfo.Optional && !parentOptional {
-					return fmt.Errorf("field %s is required but not found", fieldPath)
+					errs = errs.append(newFieldError(fieldPath, "required", "is required but not found"))
 				}
 				continue
 			}
@@ -99,49 +108,53 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 		// Process environment variables in value
 		processedValue, err := processEnvValue(value, option.UseEnv)
 		if err != nil {
-			return fmt.Errorf("field %s environment variable error: %w", fieldPath, err)
+			errs = errs.append(fmt.Errorf("field %s environment variable error: %w", fieldPath, err))
+			continue
 		}
 
-		// Validate value
-		if err := validateValue(processedValue, tagInfo, fieldPath); err != nil {
-			return err
+		// Decrypt encrypted values
+		processedValue, err = decryptValue(processedValue, option)
+		if err != nil {
+			errs = errs.append(fmt.Errorf("field %s secret error: %w", fieldPath, err))
+			continue
 		}
 
-		// Handle watch callback for updates
-		if isUpdate && tagInfo.Watch && option.WatchCallback != nil {
-			oldValue := field.Interface()
-			if err := option.WatchCallback(fieldPath, fieldName, oldValue, processedValue); err != nil {
-				return fmt.Errorf("watch callback error for field %s: %w", fieldPath, err)
-			}
+		// Validate value
+		if err := validateValue(processedValue, tagInfo, fieldPath); err != nil {
+			errs = errs.append(err)
+			continue
 		}
 
 		// Handle struct fields with value
-		if field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct) {
+		if isNestedStruct(field.Type()) {
 			if valueMap, ok := processedValue.(map[string]any); ok {
 				if field.Kind() == reflect.Ptr {
 					if field.IsNil() {
 						field.Set(reflect.New(field.Type().Elem()))
 					}
-					if err := mapToStructWithPath(valueMap, field.Interface(), option, fieldPath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
-						return err
-					}
+					errs = errs.append(mapToStructWithPath(valueMap, field.Interface(), option, fieldPath, isUpdate, parentOptional || tagInfo.Optional))
 				} else {
-					if err := mapToStructWithPath(valueMap, field.Addr().Interface(), option, fieldPath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
-						return err
-					}
+					errs = errs.append(mapToStructWithPath(valueMap, field.Addr().Interface(), option, fieldPath, isUpdate, parentOptional || tagInfo.Optional))
 				}
 			} else {
-				return fmt.Errorf("field %s expected map for struct, got %T", fieldPath, processedValue)
+				errs = errs.append(fmt.Errorf("field %s expected map for struct, got %T", fieldPath, processedValue))
 			}
 		} else {
 			// Set field value for non-struct types
-			if err := setFieldValue(field, processedValue, fieldPath); err != nil {
-				return err
+			if err := setFieldValue(field, processedValue, fieldPath, option); err != nil {
+				errs = errs.append(err)
+				continue
 			}
 		}
+
+		// Run custom validators on the typed value
+		errs = errs.append(runCustomValidators(field, tagInfo, fieldPath))
 	}
 
-	return nil
+	// Cross-field rules need all siblings to be set
+	errs = errs.append(validateCrossFields(v, option, basePath))
+
+	return errs.err()
 }
 
 // handleStructField handles struct and pointer to struct fields
@@ -163,7 +176,7 @@ func handleStructField(field reflect.Value, fieldType reflect.StructField, optio
 }
 
 // setFieldValue sets field value with type conversion
-func setFieldValue(field reflect.Value, value any, fieldPath string) error {
+func setFieldValue(field reflect.Value, value any, fieldPath string, option *Option) error {
 	if value == nil {
 		return nil
 	}
@@ -186,15 +199,31 @@ func setFieldValue(field reflect.Value, value any, fieldPath string) error {
 		if field.IsNil() {
 			field.Set(reflect.New(fieldType.Elem()))
 		}
-		return setFieldValue(field.Elem(), value, fieldPath)
+		return setFieldValue(field.Elem(), value, fieldPath, option)
 	}
 
-	// Handle struct types
-	if fieldType.Kind() == reflect.Struct {
-		if valueMap, ok := value.(map[string]any); ok {
-			// This should be handled by mapToStructWithPath
-			return fmt.Errorf("struct field %s should be handled by mapToStructWithPath, got map: %v", fieldPath, valueMap)
+	// Handle time.Time with the layouts and relative expressions like "now-1h" of cast
+	if str, ok := value.(string); ok && fieldType == timeType {
+		t, err := cast.ToTimeE(str)
+		if err != nil {
+			return fmt.Errorf("field %s time parse error: %w", fieldPath, err)
 		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	// Handle text types like net.IP and ByteSize
+	if handled, err := setTextValue(field, value, fieldPath); handled {
+		return err
+	}
+
+	// Handle struct types, e.g. elements of slices and maps
+	if isNestedStruct(fieldType) {
+		valueMap, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("field %s expected map for struct, got %T", fieldPath, value)
+		}
+		return mapToStructWithPath(valueMap, field.Addr().Interface(), option, fieldPath, false, false)
 	}
 
 	// Direct assignment if types match
@@ -204,11 +233,11 @@ func setFieldValue(field reflect.Value, value any, fieldPath string) error {
 	}
 
 	// Type conversion
-	return convertAndSetValue(field, value, fieldPath)
+	return convertAndSetValue(field, value, fieldPath, option)
 }
 
 // convertAndSetValue converts value to field type and sets it
-func convertAndSetValue(field reflect.Value, value any, fieldPath string) error {
+func convertAndSetValue(field reflect.Value, value any, fieldPath string, option *Option) error {
 	fieldType := field.Type()
 
 	switch fieldType.Kind() {
@@ -248,10 +277,10 @@ func convertAndSetValue(field reflect.Value, value any, fieldPath string) error
 		}
 
 	case reflect.Slice:
-		return setSliceValue(field, value, fieldPath)
+		return setSliceValue(field, value, fieldPath, option)
 
 	case reflect.Map:
-		return setMapValue(field, value, fieldPath)
+		return setMapValue(field, value, fieldPath, option)
 
 	default:
 		return fmt.Errorf("field %s unsupported type conversion from %T to %s", fieldPath, value, fieldType.Kind())
@@ -386,7 +415,7 @@ func setFloatValue(field reflect.Value, value any, fieldPath string) error {
 }
 
 // setSliceValue sets slice field value
-func setSliceValue(field reflect.Value, value any, fieldPath string) error {
+func setSliceValue(field reflect.Value, value any, fieldPath string, option *Option) error {
 	valueSlice, ok := value.([]any)
 	if !ok {
 		return fmt.Errorf("field %s expected slice, got %T", fieldPath, value)
@@ -397,11 +426,13 @@ func setSliceValue(field reflect.Value, value any, fieldPath string) error {
 	// Pre-allocate slice capacity
 	newSlice := reflect.MakeSlice(sliceType, len(valueSlice), len(valueSlice))
 
+	var errs ValidationErrors
 	for i, item := range valueSlice {
 		elem := newSlice.Index(i)
-		if err := setFieldValue(elem, item, fmt.Sprintf("%s[%d]", fieldPath, i)); err != nil {
-			return err
-		}
+		errs = errs.append(setFieldValue(elem, item, fmt.Sprintf("%s[%d]", fieldPath, i), option))
+	}
+	if err := errs.err(); err != nil {
+		return err
 	}
 
 	field.Set(newSlice)
@@ -409,7 +440,7 @@ func setSliceValue(field reflect.Value, value any, fieldPath string) error {
 }
 
 // setMapValue sets map field value
-func setMapValue(field reflect.Value, value any, fieldPath string) error {
+func setMapValue(field reflect.Value, value any, fieldPath string, option *Option) error {
 	valueMap, ok := value.(map[string]any)
 	if !ok {
 		return fmt.Errorf("field %s expected map, got %T", fieldPath, value)
@@ -427,12 +458,17 @@ func setMapValue(field reflect.Value, value any, fieldPath string) error {
 	// Pre-allocate map capacity
 	newMap := reflect.MakeMapWithSize(mapType, len(valueMap))
 
+	var errs ValidationErrors
 	for k, v := range valueMap {
 		mapValue := reflect.New(valueType).Elem()
-		if err := setFieldValue(mapValue, v, fmt.Sprintf("%s[%s]", fieldPath, k)); err != nil {
-			return err
+		if err := setFieldValue(mapValue, v, fmt.Sprintf("%s[%s]", fieldPath, k), option); err != nil {
+			errs = errs.append(err)
+			continue
 		}
-		newMap.SetMapIndex(reflect.ValueOf(k), mapValue)
+		newMap.SetMapIndex(reflect.ValueOf(k).Convert(keyType), mapValue)
+	}
+	if err := errs.err(); err != nil {
+		return err
 	}
 
 	field.Set(newMap)
@@ -1,5 +1,11 @@
 package conf
 
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
 // MatchMode represents field name matching mode
 type MatchMode int
 
@@ -10,6 +16,14 @@ const (
 	MatchSnakeCase                   // Snake case matching
 )
 
+// SliceMergeMode represents how slices are merged between config layers
+type SliceMergeMode int
+
+const (
+	SliceReplace SliceMergeMode = iota // Overlay slice replaces base slice
+	SliceAppend                        // Overlay slice is appended to base slice
+)
+
 // WatchCallback is the callback function for field changes
 type WatchCallback func(path, key string, oldValue, newValue any) error
 
@@ -21,6 +35,30 @@ type Option struct {
 	Updatable     bool          // Whether to support updates
 	HotReload     bool          // Whether to enable hot reload
 	WatchCallback WatchCallback // Watch callback function
+	Persist       bool          // Whether to write updates back to the config file
+	HistorySize   int           // Number of applied versions kept for Rollback, default 10, 0 disables the history
+	Debounce      time.Duration // Delay to coalesce file change events before reloading, default 100ms
+
+	Profile    string         // Profile overlay name, e.g. "prod" for config.prod.yaml
+	ProfileEnv string         // Environment variable to read profile from, default "APP_PROFILE"
+	SliceMerge SliceMergeMode // Slice merge mode between layers
+
+	AutoEnv      bool   // Whether to override fields from environment variables derived from tag paths
+	EnvPrefix    string // Prefix of derived environment variable names, e.g. "APP"
+	EnvSeparator string // Separator of derived environment variable names, default "_"
+
+	FlagSet *flag.FlagSet // Parsed flag set overriding fields, see BindFlags
+
+	Validators   []func(any) error // Validation hooks run on every candidate config
+	ErrorHandler func(error)       // Reload error handler, errors are logged with zlog when nil
+
+	Name     string    // Instance name, configs of the same type are registered per name
+	Registry *Registry // Registry to register the config in, the default registry when nil
+
+	SecretKey []byte // Key to decrypt "enc:v1:..." values, loaded from CONF_SECRET_KEY or CONF_SECRET_KEY_FILE when nil
+
+	StrictCast bool // Whether GetAs fails on overflow and precision loss, see cast.StrictToE
+	StrictTags bool // Whether malformed or unknown tag parts fail mapping instead of being ignored
 }
 
 // NewOption creates a new Option with default values
@@ -32,6 +70,12 @@ func NewOption() *Option {
 		Updatable:     false,
 		HotReload:     false,
 		WatchCallback: nil,
+		HistorySize:   10,
+		Debounce:      100 * time.Millisecond,
+		ProfileEnv:    "APP_PROFILE",
+		SliceMerge:    SliceReplace,
+		AutoEnv:       false,
+		EnvSeparator:  "_",
 	}
 }
 
@@ -76,3 +120,131 @@ func WithWatchCallback(callback WatchCallback) func(*Option) {
 		o.WatchCallback = callback
 	}
 }
+
+// WithPersist sets whether Update writes the updated keys back to the config
+// file in its format, JSON, YAML or TOML. Files are replaced atomically, YAML
+// files keep their comments and key order. With layers the keys go to the
+// top-most existing layer, and secret fields are stored encrypted.
+func WithPersist(persist bool) func(*Option) {
+	return func(o *Option) {
+		o.Persist = persist
+	}
+}
+
+// WithHistorySize sets the number of applied versions kept for Rollback
+func WithHistorySize(size int) func(*Option) {
+	return func(o *Option) {
+		o.HistorySize = size
+	}
+}
+
+// WithDebounce sets the delay to coalesce file change events before reloading
+func WithDebounce(debounce time.Duration) func(*Option) {
+	return func(o *Option) {
+		o.Debounce = debounce
+	}
+}
+
+// WithProfile sets the profile overlay name, it takes precedence over ProfileEnv
+func WithProfile(profile string) func(*Option) {
+	return func(o *Option) {
+		o.Profile = profile
+	}
+}
+
+// WithProfileEnv sets the environment variable to read profile from
+func WithProfileEnv(env string) func(*Option) {
+	return func(o *Option) {
+		o.ProfileEnv = env
+	}
+}
+
+// WithSliceMerge sets the slice merge mode between layers
+func WithSliceMerge(mode SliceMergeMode) func(*Option) {
+	return func(o *Option) {
+		o.SliceMerge = mode
+	}
+}
+
+// WithAutoEnv enables field overrides from environment variables, names are
+// derived from tag paths as PREFIX + SEP + PATH, e.g. APP_DATABASE_HOST for
+// Database.Host, or set explicitly with the env=NAME tag part
+func WithAutoEnv(prefix string) func(*Option) {
+	return func(o *Option) {
+		o.AutoEnv = true
+		o.EnvPrefix = prefix
+	}
+}
+
+// WithEnvSeparator sets the separator of derived environment variable names
+func WithEnvSeparator(sep string) func(*Option) {
+	return func(o *Option) {
+		o.EnvSeparator = sep
+	}
+}
+
+// WithFlagSet sets the flag set whose parsed flags override file and environment values
+func WithFlagSet(fs *flag.FlagSet) func(*Option) {
+	return func(o *Option) {
+		o.FlagSet = fs
+	}
+}
+
+// WithValidateFunc adds a validation hook run on every candidate config before
+// it is applied, a failing hook rejects the load or reload
+func WithValidateFunc[T any](fn func(*T) error) func(*Option) {
+	return func(o *Option) {
+		o.Validators = append(o.Validators, func(v any) error {
+			target, ok := v.(*T)
+			if !ok {
+				return fmt.Errorf("validate func expects %T, got %T", target, v)
+			}
+			return fn(target)
+		})
+	}
+}
+
+// WithErrorHandler sets the handler of errors during hot reload
+func WithErrorHandler(handler func(error)) func(*Option) {
+	return func(o *Option) {
+		o.ErrorHandler = handler
+	}
+}
+
+// WithSecretKey sets the key to decrypt encrypted values
+func WithSecretKey(key []byte) func(*Option) {
+	return func(o *Option) {
+		o.SecretKey = key
+	}
+}
+
+// WithName sets the instance name of the config, see LoadNamed
+func WithName(name string) func(*Option) {
+	return func(o *Option) {
+		o.Name = name
+	}
+}
+
+// WithRegistry sets the registry to register the config in, e.g. an
+// isolated registry created with NewRegistry in tests
+func WithRegistry(registry *Registry) func(*Option) {
+	return func(o *Option) {
+		o.Registry = registry
+	}
+}
+
+// WithStrictCast sets whether GetAs converts values with cast.StrictToE, failing
+// on overflow, truncation and lossy string parses instead of coercing silently
+func WithStrictCast(strict bool) func(*Option) {
+	return func(o *Option) {
+		o.StrictCast = strict
+	}
+}
+
+// WithStrictTags sets whether malformed or unknown tag parts, e.g. a pattern
+// cut at an unquoted comma, fail mapping instead of being ignored
+func WithStrictTags(strict bool) func(*Option) {
+	return func(o *Option) {
+		o.StrictTags = strict
+	}
+}
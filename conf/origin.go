@@ -0,0 +1,269 @@
+package conf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OriginKind represents where a configuration value came from
+type OriginKind string
+
+const (
+	OriginDefault OriginKind = "default" // Default tag value
+	OriginFile    OriginKind = "file"    // Config file
+	OriginSource  OriginKind = "source"  // Config source or content
+	OriginEnv     OriginKind = "env"     // Environment variable override
+	OriginFlag    OriginKind = "flag"    // Command-line flag override
+	OriginUpdate  OriginKind = "update"  // Runtime update
+	OriginUnset   OriginKind = "unset"   // Optional field without value, left as zero value
+)
+
+// Origin describes where a configuration value came from
+type Origin struct {
+	Kind OriginKind // Origin kind
+	Name string     // File path, source name, environment variable or flag name
+	Line int        // Line in the file, 0 if unknown
+}
+
+// String returns a human readable description like "file config.yaml:12"
+func (o Origin) String() string {
+	switch {
+	case o.Name == "":
+		return string(o.Kind)
+	case o.Line > 0:
+		return fmt.Sprintf("%s %s:%d", o.Kind, o.Name, o.Line)
+	default:
+		return string(o.Kind) + " " + o.Name
+	}
+}
+
+// Origins maps dotted raw map paths to the origin of their values
+type Origins map[string]Origin
+
+// setLeaves records origin for every leaf of value below path, lines
+// optionally holds the line of each path
+func (o Origins) setLeaves(path string, value any, origin Origin, lines map[string]int) {
+	if m, ok := value.(map[string]any); ok && len(m) > 0 {
+		for key, item := range m {
+			o.setLeaves(joinPath(path, key), item, origin, lines)
+		}
+		return
+	}
+
+	if path == "" {
+		return
+	}
+	leaf := origin
+	if line, ok := lines[path]; ok {
+		leaf.Line = line
+	}
+	o[path] = leaf
+}
+
+// merge copies the origins of other into o
+func (o Origins) merge(other Origins) {
+	for path, origin := range other {
+		o[path] = origin
+	}
+}
+
+// clone returns a copy of o
+func (o Origins) clone() Origins {
+	result := make(Origins, len(o))
+	result.merge(o)
+	return result
+}
+
+// lookup returns the origin of path, falling back to a case insensitive match
+func (o Origins) lookup(path string, mode MatchMode) (Origin, bool) {
+	if origin, ok := o[path]; ok {
+		return origin, true
+	}
+	if mode == MatchIgnoreCase {
+		for p, origin := range o {
+			if strings.EqualFold(p, path) {
+				return origin, true
+			}
+		}
+	}
+	return Origin{}, false
+}
+
+// joinPath joins a dotted path and a key
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// lineIndex returns the line of every key path of a config document,
+// unknown formats return an empty index
+func lineIndex(data []byte, format string) map[string]int {
+	lines := make(map[string]int)
+	switch format {
+	case "yaml", "yml":
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err == nil {
+			yamlLines(&node, "", lines)
+		}
+	case "json":
+		jsonLines(data, lines)
+	case "toml":
+		tomlLines(data, lines)
+	}
+	return lines
+}
+
+// yamlLines collects key lines of a YAML node tree
+func yamlLines(node *yaml.Node, path string, lines map[string]int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			yamlLines(child, path, lines)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinPath(path, key.Value)
+			lines[keyPath] = key.Line
+			yamlLines(value, keyPath, lines)
+		}
+	}
+}
+
+// jsonLines collects key lines of a JSON document
+func jsonLines(data []byte, lines map[string]int) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	// stack holds every open container with the pending key of objects
+	type frame struct {
+		path    string
+		isArray bool
+		key     *string
+	}
+	var stack []frame
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	expectKey := func() bool {
+		return len(stack) > 0 && !stack[len(stack)-1].isArray && stack[len(stack)-1].key == nil
+	}
+	// valuePath returns the path of the value being read and consumes the pending key
+	valuePath := func() string {
+		if len(stack) == 0 {
+			return ""
+		}
+		top := &stack[len(stack)-1]
+		if top.isArray || top.key == nil {
+			return top.path
+		}
+		path := joinPath(top.path, *top.key)
+		top.key = nil
+		return path
+	}
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+
+		if s, ok := token.(string); ok && expectKey() {
+			// InputOffset points before the separator, skip to the key itself
+			rest := data[offset:]
+			offset += int64(bytes.IndexByte(rest, '"'))
+			key := s
+			stack[len(stack)-1].key = &key
+			lines[joinPath(stack[len(stack)-1].path, s)] = lineAt(offset)
+			continue
+		}
+
+		switch token {
+		case json.Delim('{'):
+			stack = append(stack, frame{path: valuePath()})
+		case json.Delim('['):
+			stack = append(stack, frame{path: valuePath(), isArray: true})
+		case json.Delim('}'), json.Delim(']'):
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			valuePath()
+		}
+	}
+}
+
+// tomlLines collects key lines of a TOML document, it understands table
+// headers and dotted keys which covers the common layouts
+func tomlLines(data []byte, lines map[string]int) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	table := ""
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			header := strings.Trim(strings.SplitN(line, "#", 2)[0], "[] \t")
+			table = tomlKeyPath(header)
+			if table != "" {
+				if _, exists := lines[table]; !exists {
+					lines[table] = lineNo
+				}
+			}
+			continue
+		}
+
+		key, _, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		lines[joinPath(table, tomlKeyPath(strings.TrimSpace(key)))] = lineNo
+	}
+}
+
+// tomlKeyPath converts a TOML key like a."b.c".d to a dotted path, quoted
+// segments keep their dots
+func tomlKeyPath(key string) string {
+	var parts []string
+	for key != "" {
+		key = strings.TrimSpace(key)
+		var part string
+		switch {
+		case strings.HasPrefix(key, `"`):
+			end := strings.Index(key[1:], `"`)
+			if end < 0 {
+				return ""
+			}
+			part, _ = strconv.Unquote(key[:end+2])
+			key = key[end+2:]
+		case strings.HasPrefix(key, "'"):
+			end := strings.Index(key[1:], "'")
+			if end < 0 {
+				return ""
+			}
+			part = key[1 : end+1]
+			key = key[end+2:]
+		default:
+			end := strings.IndexByte(key, '.')
+			if end < 0 {
+				end = len(key)
+			}
+			part = strings.TrimSpace(key[:end])
+			key = key[end:]
+		}
+		parts = append(parts, part)
+		key = strings.TrimPrefix(strings.TrimSpace(key), ".")
+	}
+	return strings.Join(parts, ".")
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
@@ -18,17 +19,83 @@ func parseConfigFile(filename string) (map[string]any, error) {
 		return nil, fmt.Errorf("failed to read config file %s: %w", filename, err)
 	}
 
-	ext := strings.ToLower(filepath.Ext(filename))
-	switch ext {
-	case ".json":
-		return parseJSON(data)
-	case ".yaml", ".yml":
-		return parseYAML(data)
-	case ".toml":
-		return parseTOML(data)
-	default:
-		return nil, fmt.Errorf("unsupported config file format: %s", ext)
+	return parseConfigData(data, formatOf(filename))
+}
+
+// parseConfigFileWithLines parses configuration file and returns rawMap with
+// the line of every key path
+func parseConfigFileWithLines(filename string) (map[string]any, map[string]int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file %s: %w", filename, err)
+	}
+
+	format := formatOf(filename)
+	rawMap, err := parseConfigData(data, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rawMap, lineIndex(data, format), nil
+}
+
+// formatOf returns the config format of filename by its extension
+func formatOf(filename string) string {
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+}
+
+// Parser parses config data into a nested raw map
+type Parser func(data []byte) (map[string]any, error)
+
+// Parser registry keyed by format, the file extension without the dot
+var (
+	parserMu sync.RWMutex
+	parsers  = map[string]Parser{
+		"json":       parseJSON,
+		"yaml":       parseYAML,
+		"yml":        parseYAML,
+		"toml":       parseTOML,
+		"ini":        parseINI,
+		"properties": parseProperties,
+		"env":        parseDotenv,
 	}
+)
+
+// RegisterParser registers parser for format, the file extension without
+// the dot, e.g. "hcl". It replaces the parser already registered for format.
+func RegisterParser(format string, parser Parser) {
+	parserMu.Lock()
+	defer parserMu.Unlock()
+	parsers[strings.ToLower(strings.TrimPrefix(format, "."))] = parser
+}
+
+// parserOf returns the parser registered for format
+func parserOf(format string) (Parser, bool) {
+	parserMu.RLock()
+	defer parserMu.RUnlock()
+	parser, ok := parsers[format]
+	return parser, ok
+}
+
+// isSupportedFormat reports whether format can be parsed
+func isSupportedFormat(format string) bool {
+	_, ok := parserOf(format)
+	return ok
+}
+
+// parseConfigData parses data in the given format and returns rawMap
+func parseConfigData(data []byte, format string) (map[string]any, error) {
+	parser, ok := parserOf(format)
+	if !ok {
+		return nil, fmt.Errorf("unsupported config file format: .%s", format)
+	}
+	rawMap, err := parser(data)
+	if err != nil {
+		return nil, err
+	}
+	if rawMap == nil {
+		rawMap = make(map[string]any)
+	}
+	return rawMap, nil
 }
 
 // parseJSON parses JSON data and returns rawMap
@@ -0,0 +1,201 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/meta-apex/gopkg/cast"
+)
+
+// pathSegment is a map key or a list index of a path
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parsePath parses a path like "a.b[2].c" into its segments, the empty path
+// has no segments
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	for path != "" {
+		switch {
+		case path[0] == '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated index", path)
+			}
+			index, err := strconv.Atoi(path[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path %q: bad index %q", path, path[1:end])
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			path = path[end+1:]
+		case path[0] == '.':
+			path = path[1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, pathSegment{key: path[:end]})
+			path = path[end:]
+		}
+	}
+	return segments, nil
+}
+
+// joinSubPath joins a base path and a relative path, indexes attach without a dot
+func joinSubPath(base, path string) string {
+	if base == "" || strings.HasPrefix(path, "[") {
+		return base + path
+	}
+	if path == "" {
+		return base
+	}
+	return base + "." + path
+}
+
+// Getter looks up raw values by path, it is implemented by Config and View
+type Getter interface {
+	// GetValue gets the raw value by a path like "a.b[2].c"
+	GetValue(path string) (any, bool)
+	// Decode decodes the value at path into out with the tag rules of the config
+	Decode(path string, out any) error
+
+	getterOption() *Option
+}
+
+// GetAs gets the value at a path like "a.b[2].c" converted to T with cast,
+// strictly with WithStrictCast, placeholders and encrypted values are resolved
+// like for struct fields
+func GetAs[T cast.Basic](g Getter, path string) (T, error) {
+	var zero T
+
+	value, exists := g.GetValue(path)
+	if !exists {
+		return zero, fmt.Errorf("config path %s not found", path)
+	}
+	option := g.getterOption()
+	value, err := resolveValue(value, option)
+	if err != nil {
+		return zero, fmt.Errorf("config path %s: %w", path, err)
+	}
+
+	convert := cast.ToE[T]
+	if option.StrictCast {
+		convert = cast.StrictToE[T]
+	}
+	result, err := convert(value)
+	if err != nil {
+		return zero, fmt.Errorf("config path %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// GetAsOr gets the value at path converted to T, or defaultValue when the path
+// does not exist or cannot be converted
+func GetAsOr[T cast.Basic](g Getter, path string, defaultValue T) T {
+	result, err := GetAs[T](g, path)
+	if err != nil {
+		return defaultValue
+	}
+	return result
+}
+
+// resolveValue resolves placeholders and encrypted values of a raw scalar
+func resolveValue(value any, option *Option) (any, error) {
+	value, err := processEnvValue(value, option.UseEnv)
+	if err != nil {
+		return nil, err
+	}
+	return decryptValue(value, option)
+}
+
+// Decode decodes the raw value at path into out with the same tag rules as
+// the main target: names, defaults, validation and Validator implementations.
+// out must be a pointer, usually to a struct, the empty path decodes the
+// whole configuration.
+func (c *Config) Decode(path string, out any) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("decode target must be a non-nil pointer, got %T", out)
+	}
+
+	value, exists := c.GetValue(path)
+	if !exists {
+		// Missing sub-trees decode from defaults like missing nested structs
+		if !isNestedStruct(v.Type().Elem()) {
+			return fmt.Errorf("config path %s not found", path)
+		}
+		value = map[string]any{}
+	}
+
+	if _, isMap := value.(map[string]any); !isMap {
+		var err error
+		if value, err = resolveValue(value, c.option); err != nil {
+			return fmt.Errorf("config path %s: %w", path, err)
+		}
+	}
+	if err := setFieldValue(v.Elem(), value, path, c.option); err != nil {
+		return err
+	}
+	return callValidate(v, path)
+}
+
+// getterOption implements Getter
+func (c *Config) getterOption() *Option {
+	return c.option
+}
+
+// View is a live view of a sub-tree of a Config, lookups always see the
+// current configuration
+type View struct {
+	config *Config
+	path   string
+}
+
+// Sub returns a view of the sub-tree at path
+func (c *Config) Sub(path string) *View {
+	return &View{config: c, path: path}
+}
+
+// Sub returns a view of the sub-tree at path relative to v
+func (v *View) Sub(path string) *View {
+	return &View{config: v.config, path: joinSubPath(v.path, path)}
+}
+
+// Path returns the path of the view in the configuration
+func (v *View) Path() string {
+	return v.path
+}
+
+// Exists reports whether the sub-tree of the view exists
+func (v *View) Exists() bool {
+	_, exists := v.config.GetValue(v.path)
+	return exists
+}
+
+// GetValue gets the raw value by a path relative to the view
+func (v *View) GetValue(path string) (any, bool) {
+	return v.config.GetValue(joinSubPath(v.path, path))
+}
+
+// GetMap returns the raw map of the view, or nil if it is not a map
+func (v *View) GetMap() map[string]any {
+	value, _ := v.config.GetValue(v.path)
+	m, _ := cloneValue(value).(map[string]any)
+	return m
+}
+
+// Decode decodes the value at a path relative to the view into out, see Config.Decode
+func (v *View) Decode(path string, out any) error {
+	return v.config.Decode(joinSubPath(v.path, path), out)
+}
+
+// getterOption implements Getter
+func (v *View) getterOption() *Option {
+	return v.config.option
+}
@@ -0,0 +1,264 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/meta-apex/gopkg/internal/fileutil"
+	"gopkg.in/yaml.v3"
+)
+
+// persist writes the updated keys of m back to the config file of c in its
+// own format. YAML files keep their comments and key order, JSON and TOML
+// files are rewritten from the merged map.
+//
+// Keys are written to the top-most existing layer, e.g. the profile overlay,
+// so they override the lower layers and includes after a restart. Values of
+// secret fields are encrypted before they are written.
+func (c *Config) persist(m map[string]any) error {
+	if c.file == "" {
+		return fmt.Errorf("config has no file to persist to")
+	}
+
+	m, err := c.encryptSecrets(m)
+	if err != nil {
+		return err
+	}
+
+	c.persistMu.Lock()
+	defer c.persistMu.Unlock()
+
+	// Write through symlinks, e.g. a config file linked from a shared directory
+	target := c.persistFile()
+	file, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", file, err)
+	}
+
+	var updated []byte
+	switch format := formatOf(target); format {
+	case "yaml", "yml":
+		updated, err = updateYAML(data, m, c.option)
+	case "json":
+		updated, err = updateJSON(data, m, c.option)
+	case "toml":
+		updated, err = updateTOML(data, m, c.option)
+	default:
+		return fmt.Errorf("persisting .%s config files is not supported", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := fileutil.WriteFileAtomic(file, updated); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", file, err)
+	}
+
+	// Keep the watcher from reloading its own write
+	if c.watcher != nil {
+		c.watcher.markWritten()
+	}
+	return nil
+}
+
+// persistFile returns the top-most existing layer file of c
+func (c *Config) persistFile() string {
+	for i := len(c.files) - 1; i >= 0; i-- {
+		if _, err := os.Stat(c.files[i].file); err == nil {
+			return c.files[i].file
+		}
+	}
+	return c.file
+}
+
+// encryptSecrets returns a copy of m with the plain values of secret fields
+// encrypted, so updates never store secrets in plain text
+func (c *Config) encryptSecrets(m map[string]any) (map[string]any, error) {
+	result := cloneValue(m).(map[string]any)
+
+	var err error
+	walkFields(reflect.TypeOf(c.target), c.option, nil, func(path []string, field reflect.StructField, tagInfo *TagInfo) {
+		if err != nil || !tagInfo.Secret {
+			return
+		}
+
+		current := result
+		for _, part := range path[:len(path)-1] {
+			key, ok := findKeyInMap(current, part, c.option.MatchMode)
+			if !ok {
+				return
+			}
+			if current, ok = current[key].(map[string]any); !ok {
+				return
+			}
+		}
+		key, ok := findKeyInMap(current, path[len(path)-1], c.option.MatchMode)
+		if !ok || current[key] == nil {
+			return
+		}
+
+		plain, ok := current[key].(string)
+		if !ok {
+			err = fmt.Errorf("secret %s must be a string to be persisted", strings.Join(path, "."))
+			return
+		}
+		if IsSecret(plain) {
+			return
+		}
+
+		secretKey, keyErr := c.option.secretKey()
+		if keyErr != nil {
+			err = fmt.Errorf("cannot encrypt secret %s: %w", strings.Join(path, "."), keyErr)
+			return
+		}
+		current[key], err = EncryptSecret(plain, secretKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// updateYAML applies m to a YAML document, keeping comments and key order
+func updateYAML(data []byte, m map[string]any, option *Option) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("YAML document is not a mapping")
+	}
+
+	if err := updateYAMLMapping(root, m, option); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(detectIndent(data))
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// updateYAMLMapping applies m to a YAML mapping node, replaced values keep
+// the comments of the values they replace and nil values remove their keys
+func updateYAMLMapping(node *yaml.Node, m map[string]any, option *Option) error {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := m[key]
+
+		index := -1
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i].Value
+			if k == key || option.MatchMode == MatchIgnoreCase && strings.EqualFold(k, key) {
+				index = i
+				break
+			}
+		}
+
+		if value == nil {
+			if index >= 0 {
+				node.Content = append(node.Content[:index], node.Content[index+2:]...)
+			}
+			continue
+		}
+
+		if nested, ok := value.(map[string]any); ok && index >= 0 && node.Content[index+1].Kind == yaml.MappingNode {
+			if err := updateYAMLMapping(node.Content[index+1], nested, option); err != nil {
+				return err
+			}
+			continue
+		}
+
+		valueNode := &yaml.Node{}
+		if err := valueNode.Encode(value); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+		if index < 0 {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, valueNode)
+			continue
+		}
+
+		old := node.Content[index+1]
+		valueNode.HeadComment = old.HeadComment
+		valueNode.LineComment = old.LineComment
+		valueNode.FootComment = old.FootComment
+		if old.Kind == yaml.ScalarNode && valueNode.Kind == yaml.ScalarNode && valueNode.Tag == old.Tag {
+			valueNode.Style = old.Style
+		}
+		node.Content[index+1] = valueNode
+	}
+	return nil
+}
+
+// updateJSON applies m to a JSON document
+func updateJSON(data []byte, m map[string]any, option *Option) ([]byte, error) {
+	rawMap, err := parseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if rawMap == nil {
+		rawMap = make(map[string]any)
+	}
+	mergeMaps(rawMap, m, option)
+
+	updated, err := json.MarshalIndent(rawMap, "", strings.Repeat(" ", detectIndent(data)))
+	if err != nil {
+		return nil, err
+	}
+	return append(updated, '\n'), nil
+}
+
+// updateTOML applies m to a TOML document
+func updateTOML(data []byte, m map[string]any, option *Option) ([]byte, error) {
+	rawMap, err := parseTOML(data)
+	if err != nil {
+		return nil, err
+	}
+	if rawMap == nil {
+		rawMap = make(map[string]any)
+	}
+	mergeMaps(rawMap, m, option)
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(rawMap); err != nil {
+		return nil, fmt.Errorf("failed to encode TOML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// detectIndent returns the indentation width of the first indented line, or 2
+func detectIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent > 0 && trimmed != "" {
+			return indent
+		}
+	}
+	return 2
+}
@@ -0,0 +1,150 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/meta-apex/gopkg/zlog"
+)
+
+// Registry tracks loaded configs by target type and instance name.
+//
+// Configs are registered in the default registry unless Option.Registry is
+// set, tests can use their own registry to stay isolated from each other.
+type Registry struct {
+	mu      sync.RWMutex
+	configs map[registryKey]*Config
+}
+
+// registryKey identifies a config instance in a registry
+type registryKey struct {
+	targetType reflect.Type
+	name       string
+}
+
+// defaultRegistry is the registry used when Option.Registry is nil
+var defaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{configs: make(map[registryKey]*Config)}
+}
+
+// DefaultRegistry returns the registry used when Option.Registry is nil
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// register registers c under its target type and name. Unnamed configs
+// replace the previous unnamed config of the same type with a warning, the
+// replaced config keeps running for its holders. Named configs must be unique.
+func (r *Registry) register(c *Config) error {
+	key := registryKey{targetType: reflect.TypeOf(c.target), name: c.name}
+
+	r.mu.Lock()
+	previous, exists := r.configs[key]
+	if exists && c.name != "" {
+		r.mu.Unlock()
+		return fmt.Errorf("config %q of type %s is already registered", c.name, key.targetType.Elem())
+	}
+	r.configs[key] = c
+	r.mu.Unlock()
+
+	if exists && previous != c {
+		zlog.Warn().Msgf("conf: unnamed config of type %s replaced in the registry, use WithName to keep both", key.targetType.Elem())
+	}
+	return nil
+}
+
+// get returns the config of targetType named name
+func (r *Registry) get(targetType reflect.Type, name string) *Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.configs[registryKey{targetType: targetType, name: name}]
+}
+
+// unregister removes c from the registry if it is still registered
+func (r *Registry) unregister(c *Config) bool {
+	key := registryKey{targetType: reflect.TypeOf(c.target), name: c.name}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.configs[key] != c {
+		return false
+	}
+	delete(r.configs, key)
+	return true
+}
+
+// Close closes every config in the registry, stopping their watchers
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	configs := make([]*Config, 0, len(r.configs))
+	for _, c := range r.configs {
+		configs = append(configs, c)
+	}
+	r.configs = make(map[registryKey]*Config)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, c := range configs {
+		errs = append(errs, c.StopWatcher())
+	}
+	return errors.Join(errs...)
+}
+
+// MustLoadNamed loads the configuration instance name from file, panics on error
+func MustLoadNamed[T any](name, file string, opts ...func(*Option)) *T {
+	result, err := LoadNamed[T](name, file, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// LoadNamed loads configuration from file as the instance name, so several
+// configs of the same type can be loaded side by side, e.g. "primary" and
+// "replica" database configs. Use GetNamed to look it up.
+func LoadNamed[T any](name, file string, opts ...func(*Option)) (*T, error) {
+	if name == "" {
+		return nil, fmt.Errorf("config name is empty")
+	}
+	return Load[T](file, append(opts, WithName(name))...)
+}
+
+// GetNamed gets the Config instance of type T named name from the default registry
+func GetNamed[T any](name string) *Config {
+	return GetFrom[T](defaultRegistry, name)
+}
+
+// GetFrom gets the Config instance of type T named name from registry r,
+// the unnamed instance has the empty name
+func GetFrom[T any](r *Registry, name string) *Config {
+	var target *T
+	return r.get(reflect.TypeOf(target), name)
+}
+
+// Unregister removes the Config instance of type T named name from the
+// default registry and stops its watchers
+func Unregister[T any](name string) error {
+	c := GetNamed[T](name)
+	if c == nil {
+		var target T
+		return fmt.Errorf("config %q of type %T is not registered", name, target)
+	}
+	return c.Close()
+}
+
+// Close stops the watchers of c and removes it from its registry
+func (c *Config) Close() error {
+	c.registry.unregister(c)
+	return c.StopWatcher()
+}
+
+// Name returns the instance name of c, empty for unnamed configs
+func (c *Config) Name() string {
+	return c.name
+}
@@ -0,0 +1,551 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonSchema is a JSON Schema (draft 2020-12) node
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 any                    `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *float64               `json:"minLength,omitempty"`
+	MaxLength            *float64               `json:"maxLength,omitempty"`
+	MinItems             *float64               `json:"minItems,omitempty"`
+	MaxItems             *float64               `json:"maxItems,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	UniqueItems          bool                   `json:"uniqueItems,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// schemaBuilder builds the schema of a config struct, struct types used more
+// than once or recursively are emitted once in $defs and referenced by $ref
+type schemaBuilder struct {
+	option *Option
+	counts map[reflect.Type]int
+	refs   map[reflect.Type]string
+	defs   map[string]*jsonSchema
+}
+
+// docField is a leaf field row of the Markdown reference
+type docField struct {
+	path     string
+	typeName string
+	required bool
+	tagInfo  *TagInfo
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	regexpType   = reflect.TypeOf(regexp.Regexp{})
+)
+
+// GenerateSchema generates a JSON Schema document for the config struct T.
+//
+// Property names follow the tag names or the Option.MatchMode naming of the
+// field names, and default, options, range, len, pattern, unique, optional
+// and usage tag parts are reflected in the schema.
+func GenerateSchema[T any](opts ...func(*Option)) ([]byte, error) {
+	option := NewOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	var target T
+	t := reflect.TypeOf(target)
+	if t == nil || derefType(t).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("target must be a struct, got %T", target)
+	}
+
+	b := &schemaBuilder{
+		option: option,
+		counts: make(map[reflect.Type]int),
+		refs:   make(map[reflect.Type]string),
+		defs:   make(map[string]*jsonSchema),
+	}
+	b.countTypes(derefType(t), make(map[reflect.Type]bool))
+
+	// A recursive root type is defined once and referenced by the root too
+	var root *jsonSchema
+	if b.counts[derefType(t)] > 1 {
+		root = b.refSchema(derefType(t))
+	} else {
+		root = b.structSchema(derefType(t), false)
+	}
+	if len(b.defs) > 0 {
+		root.Defs = b.defs
+	}
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	root.Title = derefType(t).Name()
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateMarkdown generates a Markdown reference of the config struct T,
+// listing every key with its type, requirement, default, allowed values and description
+func GenerateMarkdown[T any](opts ...func(*Option)) ([]byte, error) {
+	option := NewOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	var target T
+	t := reflect.TypeOf(target)
+	if t == nil || derefType(t).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("target must be a struct, got %T", target)
+	}
+
+	var fields []docField
+	collectDocFields(derefType(t), option, "", false, map[reflect.Type]string{derefType(t): ""}, &fields)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n", derefType(t).Name())
+	buf.WriteString("| Key | Type | Required | Default | Constraints | Description |\n")
+	buf.WriteString("|-----|------|----------|---------|-------------|-------------|\n")
+	for _, f := range fields {
+		required := "no"
+		if f.required {
+			required = "yes"
+		}
+		fmt.Fprintf(&buf, "| `%s` | %s | %s | %s | %s | %s |\n",
+			f.path, f.typeName, required, markdownCode(f.tagInfo.Default),
+			markdownEscape(strings.Join(constraintsOf(f.tagInfo), ", ")), markdownEscape(f.tagInfo.Usage))
+	}
+	return buf.Bytes(), nil
+}
+
+// countTypes counts the uses of the struct types reachable from t, a type
+// reached again while walking its own fields counts as used twice
+func (b *schemaBuilder) countTypes(t reflect.Type, stack map[reflect.Type]bool) {
+	t = derefType(t)
+	if isTextType(t) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		b.countTypes(t.Elem(), stack)
+	case reflect.Struct:
+		if stack[t] {
+			b.counts[t] += 2
+			return
+		}
+		b.counts[t]++
+		if b.counts[t] > 1 {
+			return
+		}
+		stack[t] = true
+		b.countFields(t, stack)
+		delete(stack, t)
+	}
+}
+
+// countFields counts the struct types of the fields of struct type t
+func (b *schemaBuilder) countFields(t reflect.Type, stack map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() || parseTag(fieldType.Tag.Get(b.option.TagName)).Skip {
+			continue
+		}
+
+		ft := derefType(fieldType.Type)
+		if fieldType.Anonymous && ft.Kind() == reflect.Struct {
+			if !stack[ft] {
+				stack[ft] = true
+				b.countFields(ft, stack)
+				delete(stack, ft)
+			}
+			continue
+		}
+		b.countTypes(ft, stack)
+	}
+}
+
+// structSchema builds the schema of struct type t
+func (b *schemaBuilder) structSchema(t reflect.Type, parentOptional bool) *jsonSchema {
+	schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+	b.addStructProperties(schema, t, parentOptional, map[reflect.Type]bool{t: true})
+	return schema
+}
+
+// refSchema returns a $ref to the $defs entry of struct type t, building the
+// entry on first use
+func (b *schemaBuilder) refSchema(t reflect.Type) *jsonSchema {
+	name, ok := b.refs[t]
+	if !ok {
+		name = t.Name()
+		for i := 2; b.isDefName(name); i++ {
+			name = t.Name() + strconv.Itoa(i)
+		}
+		b.refs[t] = name
+		b.defs[name] = b.structSchema(t, false)
+	}
+	return &jsonSchema{Ref: "#/$defs/" + name}
+}
+
+// isDefName reports whether name is taken by a $defs entry
+func (b *schemaBuilder) isDefName(name string) bool {
+	for _, n := range b.refs {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addStructProperties adds the fields of struct type t to schema, embedded structs are flattened
+func (b *schemaBuilder) addStructProperties(schema *jsonSchema, t reflect.Type, parentOptional bool, embedded map[reflect.Type]bool) {
+	option := b.option
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldType.Tag.Get(option.TagName))
+		if tagInfo.Skip {
+			continue
+		}
+
+		ft := derefType(fieldType.Type)
+		if fieldType.Anonymous && ft.Kind() == reflect.Struct {
+			if !embedded[ft] {
+				embedded[ft] = true
+				b.addStructProperties(schema, ft, parentOptional || tagInfo.Optional, embedded)
+			}
+			continue
+		}
+
+		optional := parentOptional || tagInfo.Optional
+		property := b.typeSchema(ft, optional)
+		applyTagSchema(property, ft, tagInfo)
+
+		name := keyNameOf(fieldType, tagInfo, option)
+		schema.Properties[name] = property
+		if !optional && tagInfo.Default == "" && !isNestedStruct(ft) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// typeSchema builds the schema of a field type
+func (b *schemaBuilder) typeSchema(t reflect.Type, optional bool) *jsonSchema {
+	t = derefType(t)
+	switch {
+	case t == durationType:
+		return &jsonSchema{Type: []string{"string", "integer"}, Description: "duration like 1m30s, or milliseconds"}
+	case t == byteSizeType:
+		return &jsonSchema{Type: []string{"string", "integer"}, Description: "byte size like 512MB, or bytes"}
+	case t == timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case t == urlType:
+		return &jsonSchema{Type: "string", Format: "uri"}
+	case t == regexpType:
+		return &jsonSchema{Type: "string", Format: "regex"}
+	case isTextType(t):
+		return &jsonSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := float64(0)
+		return &jsonSchema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &jsonSchema{Type: "string"}
+		}
+		return &jsonSchema{Type: "array", Items: b.typeSchema(t.Elem(), false)}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: b.typeSchema(t.Elem(), false)}
+	case reflect.Struct:
+		if t.Name() != "" && b.counts[t] > 1 {
+			return b.refSchema(t)
+		}
+		return b.structSchema(t, optional)
+	default:
+		return &jsonSchema{}
+	}
+}
+
+// applyTagSchema applies tag rules to the schema of a field of type t
+func applyTagSchema(schema *jsonSchema, t reflect.Type, tagInfo *TagInfo) {
+	if tagInfo.Usage != "" {
+		if schema.Description != "" {
+			schema.Description = tagInfo.Usage + ", " + schema.Description
+		} else {
+			schema.Description = tagInfo.Usage
+		}
+	}
+	if tagInfo.Default != "" {
+		schema.Default = typedValue(tagInfo.Default, t)
+	}
+	for _, option := range tagInfo.Options {
+		schema.Enum = append(schema.Enum, typedValue(option, t))
+	}
+	schema.Minimum = firstBound(tagInfo.RangeMin, schema.Minimum)
+	schema.Maximum = tagInfo.RangeMax
+	if tagInfo.Pattern != "" {
+		schema.Pattern = tagInfo.Pattern
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		schema.MinLength, schema.MaxLength = tagInfo.LenMin, tagInfo.LenMax
+	case reflect.Slice, reflect.Array:
+		schema.MinItems, schema.MaxItems = tagInfo.LenMin, tagInfo.LenMax
+		schema.UniqueItems = tagInfo.Unique
+		if tagInfo.Pattern != "" && schema.Items != nil {
+			schema.Items.Pattern, schema.Pattern = tagInfo.Pattern, ""
+		}
+	}
+}
+
+// collectDocFields collects the leaf fields of struct type t for the Markdown
+// reference, a struct type nested in itself is listed as a reference to the
+// path of its outer occurrence in ancestors instead of being walked again
+func collectDocFields(t reflect.Type, option *Option, basePath string, parentOptional bool, ancestors map[reflect.Type]string, fields *[]docField) {
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldType.Tag.Get(option.TagName))
+		if tagInfo.Skip {
+			continue
+		}
+
+		ft := derefType(fieldType.Type)
+		optional := parentOptional || tagInfo.Optional
+		if fieldType.Anonymous && ft.Kind() == reflect.Struct {
+			if _, ok := ancestors[ft]; !ok {
+				ancestors[ft] = basePath
+				collectDocFields(ft, option, basePath, optional, ancestors, fields)
+				delete(ancestors, ft)
+			}
+			continue
+		}
+
+		path := keyNameOf(fieldType, tagInfo, option)
+		if basePath != "" {
+			path = basePath + "." + path
+		}
+
+		typeName := typeNameOf(ft)
+		if elem := elemStructOf(ft); elem != nil {
+			if outer, ok := ancestors[elem]; ok {
+				ref := "root"
+				if outer != "" {
+					ref = "`" + outer + "`"
+				}
+				typeName += " (recursive, see " + ref + ")"
+			} else if isNestedStruct(ft) {
+				ancestors[ft] = path
+				collectDocFields(ft, option, path, optional, ancestors, fields)
+				delete(ancestors, ft)
+				continue
+			}
+		}
+
+		*fields = append(*fields, docField{
+			path:     path,
+			typeName: typeName,
+			required: !optional && tagInfo.Default == "",
+			tagInfo:  tagInfo,
+		})
+	}
+}
+
+// elemStructOf returns the struct type t is or holds as list or map elements, or nil
+func elemStructOf(t reflect.Type) reflect.Type {
+	t = derefType(t)
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = derefType(t.Elem())
+	}
+	if isNestedStruct(t) {
+		return t
+	}
+	return nil
+}
+
+// keyNameOf returns the config key of a field
+func keyNameOf(field reflect.StructField, tagInfo *TagInfo, option *Option) string {
+	if tagInfo.FieldName != "" {
+		return tagInfo.FieldName
+	}
+	return convertFieldName(field.Name, option.MatchMode)
+}
+
+// typeNameOf returns a human readable type name
+func typeNameOf(t reflect.Type) string {
+	t = derefType(t)
+	switch {
+	case t == durationType:
+		return "duration"
+	case t == byteSizeType:
+		return "byte size"
+	case t == timeType:
+		return "time"
+	case t == urlType:
+		return "url"
+	case t == regexpType:
+		return "regexp"
+	case isTextType(t):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes"
+		}
+		return "list of " + typeNameOf(t.Elem())
+	case reflect.Map:
+		return "map of " + typeNameOf(t.Elem())
+	case reflect.Struct:
+		return "object"
+	default:
+		return t.Kind().String()
+	}
+}
+
+// constraintsOf describes the validation rules of a field
+func constraintsOf(tagInfo *TagInfo) []string {
+	var result []string
+	if len(tagInfo.Options) > 0 {
+		result = append(result, "one of "+strings.Join(tagInfo.Options, ", "))
+	}
+	if tagInfo.RangeMin != nil || tagInfo.RangeMax != nil {
+		result = append(result, "range "+boundsString(tagInfo.RangeMin, tagInfo.RangeMax))
+	}
+	if tagInfo.LenMin != nil || tagInfo.LenMax != nil {
+		result = append(result, "length "+boundsString(tagInfo.LenMin, tagInfo.LenMax))
+	}
+	if tagInfo.Pattern != "" {
+		result = append(result, "pattern `"+tagInfo.Pattern+"`")
+	}
+	if tagInfo.Unique {
+		result = append(result, "unique")
+	}
+	if tagInfo.RequiredIf != "" {
+		result = append(result, "required if "+tagInfo.RequiredIf)
+	}
+	if len(tagInfo.RequiredWith) > 0 {
+		result = append(result, "required with "+strings.Join(tagInfo.RequiredWith, ", "))
+	}
+	return result
+}
+
+// boundsString formats range bounds as [min:max]
+func boundsString(lower, upper *float64) string {
+	format := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f, 'f', -1, 64)
+	}
+	return "[" + format(lower) + ":" + format(upper) + "]"
+}
+
+// typedValue converts a tag value to the JSON type of t, keeping the string if it does not parse
+func typedValue(s string, t reflect.Type) any {
+	if t == durationType || isTextType(t) {
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
+
+// firstBound returns the first non-nil bound
+func firstBound(bounds ...*float64) *float64 {
+	for _, bound := range bounds {
+		if bound != nil {
+			return bound
+		}
+	}
+	return nil
+}
+
+// derefType dereferences pointer types
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isNestedStruct reports whether t is mapped from a nested object
+func isNestedStruct(t reflect.Type) bool {
+	return derefType(t).Kind() == reflect.Struct && !isTextType(t)
+}
+
+// markdownCode formats s as inline code, or empty if s is empty
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownEscape(s) + "`"
+}
+
+// markdownEscape escapes table separators
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,172 @@
+package conf
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/meta-apex/gopkg/crypto"
+)
+
+const (
+	// SecretPrefix is the prefix of encrypted config values, "enc:v1:" followed by
+	// the standard base64 encoding of the AES-GCM nonce and ciphertext
+	SecretPrefix = "enc:v1:"
+	// SecretKeyEnv is the environment variable holding the base64 encoded secret key
+	SecretKeyEnv = "CONF_SECRET_KEY"
+	// SecretKeyFileEnv is the environment variable holding the path of the secret key file
+	SecretKeyFileEnv = "CONF_SECRET_KEY_FILE"
+)
+
+// secretAAD binds ciphertexts to the value format version
+var secretAAD = []byte(SecretPrefix)
+
+// IsSecret reports whether value is an encrypted config value
+func IsSecret(value string) bool {
+	return strings.HasPrefix(value, SecretPrefix)
+}
+
+// EncryptSecret encrypts plain with key into an "enc:v1:..." config value,
+// key must be 16, 24 or 32 bytes long
+func EncryptSecret(plain string, key []byte) (string, error) {
+	data, err := crypto.AesEncryptGCM([]byte(plain), key, secretAAD)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return SecretPrefix + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecryptSecret decrypts an "enc:v1:..." config value with key
+func DecryptSecret(value string, key []byte) (string, error) {
+	if !IsSecret(value) {
+		return "", fmt.Errorf("value is not an encrypted secret")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, SecretPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+
+	plain, err := crypto.AesDecryptGCM(data, key, secretAAD)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plain), nil
+}
+
+// GenerateSecretKey generates a random 32 bytes key, encoded in base64
+func GenerateSecretKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseSecretKey decodes a base64 encoded secret key
+func ParseSecretKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret key: %w", err)
+	}
+
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("invalid secret key length %d, expect 16, 24 or 32 bytes", len(key))
+	}
+}
+
+// LoadSecretKey loads the secret key from the CONF_SECRET_KEY environment
+// variable, or from the file named by CONF_SECRET_KEY_FILE
+func LoadSecretKey() ([]byte, error) {
+	if s := os.Getenv(SecretKeyEnv); s != "" {
+		return ParseSecretKey(s)
+	}
+
+	if file := os.Getenv(SecretKeyFileEnv); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret key file %s: %w", file, err)
+		}
+		return ParseSecretKey(string(data))
+	}
+
+	return nil, fmt.Errorf("secret key not found, set %s or %s", SecretKeyEnv, SecretKeyFileEnv)
+}
+
+// decryptValue decrypts encrypted strings in value, containers holding
+// secrets are copied so the raw map keeps the encrypted form
+func decryptValue(value any, option *Option) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if !IsSecret(v) {
+			return value, nil
+		}
+		key, err := option.secretKey()
+		if err != nil {
+			return nil, err
+		}
+		return DecryptSecret(v, key)
+	case []any:
+		if !containsSecret(v) {
+			return value, nil
+		}
+		result := make([]any, len(v))
+		for i, item := range v {
+			decrypted, err := decryptValue(item, option)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			result[i] = decrypted
+		}
+		return result, nil
+	case map[string]any:
+		if !containsSecret(v) {
+			return value, nil
+		}
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			decrypted, err := decryptValue(item, option)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			result[key] = decrypted
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// containsSecret reports whether value holds any encrypted string
+func containsSecret(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return IsSecret(v)
+	case []any:
+		for _, item := range v {
+			if containsSecret(item) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, item := range v {
+			if containsSecret(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// secretKey returns the configured secret key, or loads it from the environment
+func (o *Option) secretKey() ([]byte, error) {
+	if o.SecretKey != nil {
+		return o.SecretKey, nil
+	}
+	return LoadSecretKey()
+}
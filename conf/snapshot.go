@@ -0,0 +1,289 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Change represents a configuration change delivered to subscribers,
+// Old and New are immutable snapshots and must not be modified
+type Change[T any] struct {
+	Old *T
+	New *T
+}
+
+// Current returns the current immutable snapshot of the unnamed configuration
+// of type T, or nil if no configuration of type T is loaded. Snapshots are
+// swapped atomically on every update, so a snapshot never changes while it is
+// read. Use CurrentOf for named configs or other registries.
+func Current[T any]() *T {
+	return CurrentOf[T](Get[T]())
+}
+
+// CurrentOf returns the current immutable snapshot of c, or nil if c is nil
+// or its target type is not T
+func CurrentOf[T any](c *Config) *T {
+	if c == nil {
+		return nil
+	}
+
+	snapshot, _ := c.Snapshot().(*T)
+	return snapshot
+}
+
+// Subscribe registers fn to be called with the old and new snapshots after
+// every update of the unnamed configuration of type T. It returns a function
+// to unsubscribe. Use SubscribeOf for named configs or other registries.
+func Subscribe[T any](fn func(change Change[T])) (func(), error) {
+	return SubscribeOf[T](Get[T](), fn)
+}
+
+// SubscribeOf registers fn to be called with the old and new snapshots after
+// every update of c. It returns a function to unsubscribe.
+func SubscribeOf[T any](c *Config, fn func(change Change[T])) (func(), error) {
+	var target *T
+	if c == nil {
+		return nil, fmt.Errorf("config of type %s is not loaded", reflect.TypeOf(target).Elem())
+	}
+	if reflect.TypeOf(c.target) != reflect.TypeOf(target) {
+		return nil, fmt.Errorf("config has type %T, not %T", c.target, target)
+	}
+
+	return c.Subscribe(func(oldValue, newValue any) {
+		fn(Change[T]{Old: oldValue.(*T), New: newValue.(*T)})
+	}), nil
+}
+
+// SubscribeChan delivers the changes of the unnamed configuration of type T
+// on a channel with the given buffer size. Changes are dropped while the
+// channel is full. It returns a function to unsubscribe, which closes the
+// channel. Use SubscribeChanOf for named configs or other registries.
+func SubscribeChan[T any](size int) (<-chan Change[T], func(), error) {
+	return SubscribeChanOf[T](Get[T](), size)
+}
+
+// SubscribeChanOf delivers the changes of c on a channel like SubscribeChan,
+// the returned function may be called more than once
+func SubscribeChanOf[T any](c *Config, size int) (<-chan Change[T], func(), error) {
+	ch := make(chan Change[T], size)
+
+	// mu orders sends after close, a publish racing with unsubscribe drops its change
+	var mu sync.Mutex
+	closed := false
+	unsubscribe, err := SubscribeOf[T](c, func(change Change[T]) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- change:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ch, func() {
+		unsubscribe()
+		mu.Lock()
+		defer mu.Unlock()
+		if !closed {
+			closed = true
+			close(ch)
+		}
+	}, nil
+}
+
+// Snapshot returns the current immutable snapshot, a pointer to the target type
+func (c *Config) Snapshot() any {
+	return c.snapshot.Load()
+}
+
+// Subscribe registers fn to be called with the old and new snapshots, pointers
+// to the target type, after every update of c. It returns a function to
+// unregister it, see SubscribeOf for typed changes.
+func (c *Config) Subscribe(fn func(oldValue, newValue any)) func() {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.subscribers == nil {
+		c.subscribers = make(map[uint64]func(oldValue, newValue any))
+	}
+	c.nextSubID++
+	id := c.nextSubID
+	c.subscribers[id] = fn
+
+	return func() {
+		c.subMu.Lock()
+		delete(c.subscribers, id)
+		c.subMu.Unlock()
+	}
+}
+
+// publish notifies subscribers of a change
+func (c *Config) publish(oldValue, newValue any) {
+	c.subMu.Lock()
+	subscribers := make([]func(oldValue, newValue any), 0, len(c.subscribers))
+	for _, fn := range c.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	c.subMu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(oldValue, newValue)
+	}
+}
+
+// newSnapshot builds a fresh instance of the target type from rawMap
+func (c *Config) newSnapshot(rawMap map[string]any) (any, error) {
+	snapshot := reflect.New(reflect.TypeOf(c.target).Elem())
+	if err := mapToStruct(rawMap, snapshot.Interface(), c.option, false); err != nil {
+		return nil, err
+	}
+	if err := runValidators(snapshot.Interface(), c.option); err != nil {
+		return nil, err
+	}
+	return snapshot.Interface(), nil
+}
+
+// commit builds a snapshot from rawMap and swaps it in only if mapping,
+// validation and watch callbacks all pass, then records it in the history
+// as the applied version. The caller must hold c.mu.
+func (c *Config) commit(rawMap map[string]any, origins Origins, applied Version) (oldValue, newValue any, err error) {
+	oldValue, newValue, err = c.prepare(rawMap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.apply(rawMap, origins, applied, newValue)
+	return oldValue, newValue, nil
+}
+
+// prepare builds a snapshot from rawMap and runs validation and watch
+// callbacks on it without swapping it in. The caller must hold c.mu.
+func (c *Config) prepare(rawMap map[string]any) (oldValue, newValue any, err error) {
+	newValue, err = c.newSnapshot(rawMap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	oldValue = c.snapshot.Load()
+	if c.option.WatchCallback != nil {
+		if err := notifyWatch(reflect.ValueOf(oldValue).Elem(), reflect.ValueOf(newValue).Elem(), c.option, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	return oldValue, newValue, nil
+}
+
+// apply swaps in newValue prepared from rawMap and records it in the history
+// as the applied version. The caller must hold c.mu.
+func (c *Config) apply(rawMap map[string]any, origins Origins, applied Version, newValue any) {
+	// Keep the target returned by Load in sync for existing callers, with a
+	// deep copy so changes through the target never reach the snapshot
+	reflect.ValueOf(c.target).Elem().Set(deepCopy(reflect.ValueOf(newValue).Elem()))
+	c.rawMap = rawMap
+	c.origins = origins
+	c.snapshot.Store(newValue)
+	c.record(applied, rawMap, origins)
+}
+
+// deepCopy returns a copy of v sharing no slices, maps or pointers with it,
+// unexported struct fields are copied shallowly
+func deepCopy(v reflect.Value) reflect.Value {
+	result := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			elem := reflect.New(v.Type().Elem())
+			elem.Elem().Set(deepCopy(v.Elem()))
+			result.Set(elem)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			result.Set(deepCopy(v.Elem()))
+		}
+	case reflect.Struct:
+		result.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				result.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			result.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				result.Index(i).Set(deepCopy(v.Index(i)))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			result.Index(i).Set(deepCopy(v.Index(i)))
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			result.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			iter := v.MapRange()
+			for iter.Next() {
+				result.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+			}
+		}
+	default:
+		result.Set(v)
+	}
+	return result
+}
+
+// notifyWatch calls the watch callback for every watch field changed between oldValue and newValue
+func notifyWatch(oldValue, newValue reflect.Value, option *Option, basePath string) error {
+	t := newValue.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldType.Tag.Get(option.TagName))
+		if tagInfo.Skip {
+			continue
+		}
+
+		fieldName := fieldType.Name
+		if tagInfo.FieldName != "" {
+			fieldName = tagInfo.FieldName
+		}
+		fieldPath := fieldName
+		if fieldType.Anonymous {
+			fieldPath = basePath
+		} else if basePath != "" {
+			fieldPath = basePath + "." + fieldName
+		}
+
+		oldField, newField := oldValue.Field(i), newValue.Field(i)
+		if reflect.DeepEqual(oldField.Interface(), newField.Interface()) {
+			continue
+		}
+
+		if tagInfo.Watch {
+			if err := option.WatchCallback(fieldPath, fieldName, oldField.Interface(), newField.Interface()); err != nil {
+				return fmt.Errorf("watch callback error for field %s: %w", fieldPath, err)
+			}
+			continue
+		}
+
+		if oldField.Kind() == reflect.Ptr && !oldField.IsNil() && !newField.IsNil() {
+			oldField, newField = oldField.Elem(), newField.Elem()
+		}
+		if isNestedStruct(oldField.Type()) && isNestedStruct(newField.Type()) {
+			if err := notifyWatch(oldField, newField, option, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package conf
+
+import (
+	"sync"
+	"testing"
+)
+
+type subscribeConfig struct {
+	Port  int      `meta:"port"`
+	Hosts []string `meta:"hosts,optional"`
+}
+
+func loadSubscribeConfig(t *testing.T) *Config {
+	t.Helper()
+	registry := NewRegistry()
+	t.Cleanup(func() { registry.Close() })
+
+	if _, err := LoadFromJson[subscribeConfig]([]byte(`{"port":1,"hosts":["a"]}`), WithUpdatable(true), WithRegistry(registry)); err != nil {
+		t.Fatal(err)
+	}
+	return GetFrom[subscribeConfig](registry, "")
+}
+
+func TestSubscribeOf(t *testing.T) {
+	c := loadSubscribeConfig(t)
+
+	var changes []Change[subscribeConfig]
+	unsubscribe, err := SubscribeOf[subscribeConfig](c, func(change Change[subscribeConfig]) {
+		changes = append(changes, change)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Update(map[string]any{"port": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Old.Port != 1 || changes[0].New.Port != 2 {
+		t.Fatalf("got %+v", changes)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if err := c.Update(map[string]any{"port": 3}); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("change delivered after unsubscribe: %+v", changes)
+	}
+
+	if _, err := SubscribeOf[sourceConfig](c, func(Change[sourceConfig]) {}); err == nil {
+		t.Fatal("expected an error for a mismatched type")
+	}
+}
+
+func TestSubscribeChanOf(t *testing.T) {
+	c := loadSubscribeConfig(t)
+
+	ch, unsubscribe, err := SubscribeChanOf[subscribeConfig](c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Update(map[string]any{"port": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if change := <-ch; change.New.Port != 2 {
+		t.Fatalf("got %+v", change.New)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-ch; ok {
+		t.Fatal("channel not closed by unsubscribe")
+	}
+}
+
+func TestSubscribeChanUnsubscribeRace(t *testing.T) {
+	c := loadSubscribeConfig(t)
+
+	for i := 0; i < 100; i++ {
+		_, unsubscribe, err := SubscribeChanOf[subscribeConfig](c, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = c.Update(map[string]any{"port": i + 2})
+		}()
+		go func() {
+			defer wg.Done()
+			unsubscribe()
+		}()
+		wg.Wait()
+	}
+}
+
+func TestSnapshotIsolation(t *testing.T) {
+	c := loadSubscribeConfig(t)
+
+	target := c.GetTarget().(*subscribeConfig)
+	target.Hosts[0] = "changed"
+	if got := CurrentOf[subscribeConfig](c); got.Hosts[0] != "a" {
+		t.Fatalf("snapshot aliases the Load target: %v", got.Hosts)
+	}
+}
@@ -0,0 +1,631 @@
+package conf
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Event represents a change notification of a Source
+type Event struct {
+	Source string // Name of the changed source
+	Err    error  // Watch error, no reload happens when set
+}
+
+// Source provides raw configuration maps to a Config
+type Source interface {
+	// Name returns a human readable name of the source, e.g. a file path or URL
+	Name() string
+	// Load loads the raw configuration map
+	Load() (map[string]any, error)
+	// Watch sends an Event on every change until ctx is done,
+	// sources which cannot change return a nil channel
+	Watch(ctx context.Context) (<-chan Event, error)
+}
+
+// MustLoadSources loads configuration from sources, panics on error
+func MustLoadSources[T any](sources []Source, opts ...func(*Option)) *T {
+	result, err := LoadSources[T](sources, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// LoadSources loads configuration from multiple sources deep-merged in order,
+// with the same merge semantics as LoadLayers. When hot reload is enabled,
+// every source is watched and a change reloads all of them.
+func LoadSources[T any](sources []Source, opts ...func(*Option)) (*T, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no config sources given")
+	}
+
+	config, err := New[T](func(v *Config) error {
+		v.sources = sources
+		rawMap, origins, err := v.loadSources()
+		if err != nil {
+			return err
+		}
+		v.rawMap = rawMap
+		v.origins = origins
+		return nil
+	}, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config.target.(*T), nil
+}
+
+// loadSources loads all sources and merges them in order
+func (c *Config) loadSources() (map[string]any, Origins, error) {
+	result := make(map[string]any)
+	origins := make(Origins)
+	for _, source := range c.sources {
+		var rawMap map[string]any
+		var err error
+		if s, ok := source.(optionSource); ok {
+			rawMap, err = s.loadWith(c.option)
+		} else {
+			rawMap, err = source.Load()
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load source %s: %w", source.Name(), err)
+		}
+		mergeMaps(result, rawMap, c.option)
+		origins.setLeaves("", rawMap, Origin{Kind: OriginSource, Name: source.Name()}, nil)
+	}
+	return result, origins, nil
+}
+
+// watchSources watches all sources and reloads on changes until ctx is done
+func (c *Config) watchSources(ctx context.Context) error {
+	for _, source := range c.sources {
+		var ch <-chan Event
+		var err error
+		if s, ok := source.(optionSource); ok {
+			ch, err = s.watchWith(ctx, c.option)
+		} else {
+			ch, err = source.Watch(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to watch source %s: %w", source.Name(), err)
+		}
+		if ch == nil {
+			continue
+		}
+
+		go func() {
+			for {
+				select {
+				case event, ok := <-ch:
+					if !ok {
+						return
+					}
+					if event.Err != nil {
+						c.reportError(fmt.Errorf("failed to watch source %s: %w", event.Source, event.Err))
+						continue
+					}
+					if err := c.reload(); err != nil {
+						c.reportError(err)
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+// optionSource is a Source loaded and watched with the Option of the Config
+// it backs, e.g. for its MatchMode and Debounce
+type optionSource interface {
+	loadWith(option *Option) (map[string]any, error)
+	watchWith(ctx context.Context, option *Option) (<-chan Event, error)
+}
+
+// FileSource is a Source backed by a config file
+type FileSource struct {
+	file string
+}
+
+// NewFileSource creates a Source reading file, the format is chosen by extension
+func NewFileSource(file string) *FileSource {
+	return &FileSource{file: file}
+}
+
+// Name implements Source
+func (s *FileSource) Name() string {
+	return s.file
+}
+
+// Load implements Source
+func (s *FileSource) Load() (map[string]any, error) {
+	return parseConfigFile(s.file)
+}
+
+// Watch implements Source with the default Option
+func (s *FileSource) Watch(ctx context.Context) (<-chan Event, error) {
+	return s.watchWith(ctx, NewOption())
+}
+
+// loadWith implements optionSource
+func (s *FileSource) loadWith(option *Option) (map[string]any, error) {
+	return s.Load()
+}
+
+// watchWith implements optionSource
+func (s *FileSource) watchWith(ctx context.Context, option *Option) (<-chan Event, error) {
+	// Watch the directory to notice saves by rename and symlink swaps
+	base := filepath.Base(s.file)
+	return watchPath(ctx, filepath.Dir(s.file), s.Name(), option.Debounce, func(name string) bool {
+		return filepath.Base(name) == base || isSymlinkSwap(name)
+	}, func() string {
+		return hashFiles([]string{s.file})
+	})
+}
+
+// DirSource is a Source backed by all config files in a directory,
+// merged in file name order
+type DirSource struct {
+	dir string
+}
+
+// NewDirSource creates a Source reading every supported file in dir
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: dir}
+}
+
+// Name implements Source
+func (s *DirSource) Name() string {
+	return s.dir
+}
+
+// Load implements Source, files are merged with the default Option
+func (s *DirSource) Load() (map[string]any, error) {
+	return s.loadWith(NewOption())
+}
+
+// Watch implements Source with the default Option
+func (s *DirSource) Watch(ctx context.Context) (<-chan Event, error) {
+	return s.watchWith(ctx, NewOption())
+}
+
+// loadWith implements optionSource
+func (s *DirSource) loadWith(option *Option) (map[string]any, error) {
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]any)
+	for _, file := range files {
+		rawMap, err := parseConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		mergeMaps(result, rawMap, option)
+	}
+	return result, nil
+}
+
+// watchWith implements optionSource
+func (s *DirSource) watchWith(ctx context.Context, option *Option) (<-chan Event, error) {
+	return watchPath(ctx, s.dir, s.Name(), option.Debounce, func(name string) bool {
+		return isSupportedFormat(formatOf(name)) || isSymlinkSwap(name)
+	}, func() string {
+		files, err := s.files()
+		if err != nil {
+			return err.Error()
+		}
+		return hashFiles(files)
+	})
+}
+
+// isSymlinkSwap reports whether name is a Kubernetes ConfigMap ..data symlink
+// or ..<timestamp> directory, swapped atomically on updates
+func isSymlinkSwap(name string) bool {
+	return strings.HasPrefix(filepath.Base(name), "..")
+}
+
+// files returns supported config files of the directory in name order
+func (s *DirSource) files() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config dir %s: %w", s.dir, err)
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if !isSupportedFormat(formatOf(entry.Name())) {
+			continue
+		}
+		files = append(files, filepath.Join(s.dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// EnvSource is a Source backed by a snapshot of environment variables
+type EnvSource struct {
+	prefix    string
+	separator string
+}
+
+// NewEnvSource creates a Source from environment variables starting with prefix,
+// names are split into nested keys by separator and lowercased,
+// e.g. APP__DATABASE__HOST becomes database.host with prefix "APP" and separator "__",
+// an empty separator fails Load
+func NewEnvSource(prefix, separator string) *EnvSource {
+	return &EnvSource{prefix: prefix, separator: separator}
+}
+
+// Name implements Source
+func (s *EnvSource) Name() string {
+	return "env:" + s.prefix
+}
+
+// Load implements Source
+func (s *EnvSource) Load() (map[string]any, error) {
+	if s.separator == "" {
+		return nil, fmt.Errorf("env source %s has no separator", s.prefix)
+	}
+
+	prefix := s.prefix
+	if prefix != "" {
+		prefix += s.separator
+	}
+
+	result := make(map[string]any)
+	for _, env := range os.Environ() {
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		parts := strings.Split(strings.ToLower(strings.TrimPrefix(name, prefix)), s.separator)
+		setPathValue(result, parts, value, MatchNormal)
+	}
+	return result, nil
+}
+
+// Watch implements Source, environment snapshots never change
+func (s *EnvSource) Watch(ctx context.Context) (<-chan Event, error) {
+	return nil, nil
+}
+
+// MemorySource is a Source backed by an in-memory map, mainly used in tests
+type MemorySource struct {
+	name     string
+	rawMap   map[string]any
+	watchers []chan Event
+	mu       sync.RWMutex
+}
+
+// NewMemorySource creates a Source holding rawMap
+func NewMemorySource(name string, rawMap map[string]any) *MemorySource {
+	return &MemorySource{name: name, rawMap: rawMap}
+}
+
+// Name implements Source
+func (s *MemorySource) Name() string {
+	return s.name
+}
+
+// Load implements Source
+func (s *MemorySource) Load() (map[string]any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneValue(s.rawMap).(map[string]any), nil
+}
+
+// Set replaces the map and notifies watchers
+func (s *MemorySource) Set(rawMap map[string]any) {
+	s.mu.Lock()
+	s.rawMap = rawMap
+	watchers := append([]chan Event(nil), s.watchers...)
+	s.mu.Unlock()
+
+	for _, ch := range watchers {
+		select {
+		case ch <- Event{Source: s.name}:
+		default:
+		}
+	}
+}
+
+// Watch implements Source
+func (s *MemorySource) Watch(ctx context.Context) (<-chan Event, error) {
+	ch := make(chan Event, 1)
+	s.mu.Lock()
+	s.watchers = append(s.watchers, ch)
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		for i, w := range s.watchers {
+			if w == ch {
+				s.watchers = append(s.watchers[:i], s.watchers[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+
+	return ch, nil
+}
+
+// KVStore is a key/value store holding config values under slash separated keys
+type KVStore interface {
+	// List returns all keys with their values below prefix
+	List(ctx context.Context, prefix string) (map[string]string, error)
+}
+
+// KVSource is a Source backed by a KVStore, polled for changes
+type KVSource struct {
+	store    KVStore
+	prefix   string
+	interval time.Duration
+}
+
+// NewKVSource creates a Source from the keys below prefix in store,
+// keys like prefix/database/host become database.host
+func NewKVSource(store KVStore, prefix string, interval time.Duration) *KVSource {
+	return &KVSource{store: store, prefix: prefix, interval: interval}
+}
+
+// Name implements Source
+func (s *KVSource) Name() string {
+	return "kv:" + s.prefix
+}
+
+// Load implements Source
+func (s *KVSource) Load() (map[string]any, error) {
+	pairs, err := s.store.List(context.Background(), s.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]any)
+	for key, value := range pairs {
+		key = strings.Trim(strings.TrimPrefix(key, s.prefix), "/")
+		if key == "" {
+			continue
+		}
+		setPathValue(result, strings.Split(key, "/"), value, MatchNormal)
+	}
+	return result, nil
+}
+
+// Watch implements Source
+func (s *KVSource) Watch(ctx context.Context) (<-chan Event, error) {
+	return poll(ctx, s.Name(), s.interval, func() ([]byte, error) {
+		pairs, err := s.store.List(ctx, s.prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := make([]string, 0, len(pairs))
+		for key := range pairs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		h := sha256.New()
+		for _, key := range keys {
+			fmt.Fprintf(h, "%s=%s\n", key, pairs[key])
+		}
+		return h.Sum(nil), nil
+	}), nil
+}
+
+// HTTPSource is a Source backed by a config document served over HTTP, polled for changes
+type HTTPSource struct {
+	url      string
+	format   string
+	interval time.Duration
+	client   *http.Client
+}
+
+// NewHTTPSource creates a Source fetching url. The format is taken from
+// format, or else from the response Content-Type or the url extension.
+func NewHTTPSource(url, format string, interval time.Duration) *HTTPSource {
+	return &HTTPSource{
+		url:      url,
+		format:   format,
+		interval: interval,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Source
+func (s *HTTPSource) Name() string {
+	return s.url
+}
+
+// Load implements Source
+func (s *HTTPSource) Load() (map[string]any, error) {
+	data, contentType, err := s.fetch(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	format := s.format
+	if format == "" {
+		format = formatOfContentType(contentType)
+	}
+	if format == "" {
+		format = formatOf(s.url)
+	}
+	return parseConfigData(data, format)
+}
+
+// Watch implements Source
+func (s *HTTPSource) Watch(ctx context.Context) (<-chan Event, error) {
+	return poll(ctx, s.Name(), s.interval, func() ([]byte, error) {
+		data, _, err := s.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	}), nil
+}
+
+// fetch fetches the document and returns its body and content type
+func (s *HTTPSource) fetch(ctx context.Context) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch %s: %s", s.url, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// formatOfContentType returns the config format of a Content-Type header
+func formatOfContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	switch mediaType {
+	case "application/json":
+		return "json"
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return "yaml"
+	case "application/toml", "text/toml":
+		return "toml"
+	default:
+		return ""
+	}
+}
+
+// poll calls digest every interval and sends an Event whenever the digest changes
+func poll(ctx context.Context, name string, interval time.Duration, digest func() ([]byte, error)) <-chan Event {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	// Take the baseline before returning so changes right after Watch are seen
+	last, _ := digest()
+	ch := make(chan Event, 1)
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sum, err := digest()
+				if err != nil {
+					send(ctx, ch, Event{Source: name, Err: err})
+					continue
+				}
+				if string(sum) == string(last) {
+					continue
+				}
+				last = sum
+				send(ctx, ch, Event{Source: name})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// watchPath watches a file or directory with fsnotify and sends events debounced
+// by debounce until ctx is done, filter selects the file names which trigger an
+// event and events are dropped when the content hash is unchanged
+func watchPath(ctx context.Context, path, name string, debounce time.Duration, filter func(name string) bool, hash func() string) (<-chan Event, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(path); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	last := hash()
+	ch := make(chan Event, 1)
+	go func() {
+		defer close(ch)
+		defer watcher.Close()
+
+		var timer <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod || filter != nil && !filter(event.Name) {
+					continue
+				}
+				timer = time.After(debounce)
+			case <-timer:
+				timer = nil
+				sum := hash()
+				if sum == last {
+					continue
+				}
+				last = sum
+				send(ctx, ch, Event{Source: name})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				send(ctx, ch, Event{Source: name, Err: err})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// send sends event to ch unless ctx is done, pending events are coalesced
+func send(ctx context.Context, ch chan Event, event Event) {
+	select {
+	case ch <- event:
+	case <-ctx.Done():
+	default:
+	}
+}
@@ -0,0 +1,129 @@
+package conf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type sourceConfig struct {
+	Host string `meta:"host"`
+	Port int    `meta:"port,default=80"`
+}
+
+// waitFor polls cond until it holds or the timeout elapses
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHTTPSourcePolling(t *testing.T) {
+	var mu sync.Mutex
+	body := `{"host":"a","port":1}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	registry := NewRegistry()
+	defer registry.Close()
+
+	source := NewHTTPSource(server.URL, "", 20*time.Millisecond)
+	if _, err := LoadSources[sourceConfig]([]Source{source}, WithHotReload(true), WithUpdatable(true), WithRegistry(registry)); err != nil {
+		t.Fatal(err)
+	}
+	c := GetFrom[sourceConfig](registry, "")
+	if got := CurrentOf[sourceConfig](c); got.Host != "a" || got.Port != 1 {
+		t.Fatalf("got %+v", got)
+	}
+
+	mu.Lock()
+	body = `{"host":"b"}`
+	mu.Unlock()
+
+	waitFor(t, func() bool { return CurrentOf[sourceConfig](c).Host == "b" })
+	if got := CurrentOf[sourceConfig](c); got.Port != 80 {
+		t.Fatalf("got port %d, want default 80", got.Port)
+	}
+}
+
+func TestMemorySourceMergeAndReload(t *testing.T) {
+	registry := NewRegistry()
+	defer registry.Close()
+
+	base := NewMemorySource("base", map[string]any{"host": "a", "port": 1})
+	overlay := NewMemorySource("overlay", map[string]any{"port": 2})
+	if _, err := LoadSources[sourceConfig]([]Source{base, overlay}, WithHotReload(true), WithUpdatable(true), WithRegistry(registry)); err != nil {
+		t.Fatal(err)
+	}
+	c := GetFrom[sourceConfig](registry, "")
+	if got := CurrentOf[sourceConfig](c); got.Host != "a" || got.Port != 2 {
+		t.Fatalf("got %+v", got)
+	}
+
+	base.Set(map[string]any{"host": "b", "port": 1})
+	waitFor(t, func() bool { return CurrentOf[sourceConfig](c).Host == "b" })
+	if got := CurrentOf[sourceConfig](c); got.Port != 2 {
+		t.Fatalf("overlay lost, got %+v", got)
+	}
+}
+
+func TestDirSourceMergeAndReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "10-base.yaml"), "Host: a\nport: 1\n")
+	writeFile(t, filepath.Join(dir, "20-override.yaml"), "host: b\n")
+
+	registry := NewRegistry()
+	defer registry.Close()
+
+	if _, err := LoadSources[sourceConfig]([]Source{NewDirSource(dir)}, WithMatchMode(MatchIgnoreCase), WithHotReload(true), WithUpdatable(true), WithDebounce(10*time.Millisecond), WithRegistry(registry)); err != nil {
+		t.Fatal(err)
+	}
+	c := GetFrom[sourceConfig](registry, "")
+	if got := CurrentOf[sourceConfig](c); got.Host != "b" {
+		t.Fatalf("files not merged in name order, got %+v", got)
+	}
+
+	writeFile(t, filepath.Join(dir, "20-override.yaml"), "host: c\n")
+	waitFor(t, func() bool { return CurrentOf[sourceConfig](c).Host == "c" })
+}
+
+func TestEnvSource(t *testing.T) {
+	t.Setenv("SRCTEST__HOST", "a")
+	t.Setenv("SRCTEST__DB__PORT", "5432")
+
+	rawMap, err := NewEnvSource("SRCTEST", "__").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rawMap["host"] != "a" {
+		t.Fatalf("got %v", rawMap)
+	}
+	if db, _ := rawMap["db"].(map[string]any); db["port"] != "5432" {
+		t.Fatalf("got %v", rawMap)
+	}
+
+	if _, err := NewEnvSource("SRCTEST", "").Load(); err == nil {
+		t.Fatal("expected an error for an empty separator")
+	}
+}
+
+func writeFile(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
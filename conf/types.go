@@ -0,0 +1,175 @@
+package conf
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes read from human readable strings like "512MB",
+// "1.5GiB" or "64k". Units are case insensitive and binary, KB and KiB are
+// both 1024 bytes. Plain numbers are bytes.
+type ByteSize uint64
+
+// Byte size units
+const (
+	Byte     ByteSize = 1
+	KiloByte          = 1024 * Byte
+	MegaByte          = 1024 * KiloByte
+	GigaByte          = 1024 * MegaByte
+	TeraByte          = 1024 * GigaByte
+	PetaByte          = 1024 * TeraByte
+)
+
+// byteSizeUnits maps unit suffixes to their sizes
+var byteSizeUnits = map[string]ByteSize{
+	"":    Byte,
+	"b":   Byte,
+	"k":   KiloByte,
+	"kb":  KiloByte,
+	"kib": KiloByte,
+	"m":   MegaByte,
+	"mb":  MegaByte,
+	"mib": MegaByte,
+	"g":   GigaByte,
+	"gb":  GigaByte,
+	"gib": GigaByte,
+	"t":   TeraByte,
+	"tb":  TeraByte,
+	"tib": TeraByte,
+	"p":   PetaByte,
+	"pb":  PetaByte,
+	"pib": PetaByte,
+}
+
+// ParseByteSize parses a human readable byte size like "512MB"
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	size, ok := byteSizeUnits[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid byte size: %q", s)
+	}
+
+	if u, err := strconv.ParseUint(number, 10, 64); err == nil {
+		if u > uint64(^ByteSize(0)/size) {
+			return 0, fmt.Errorf("byte size %q overflows", s)
+		}
+		return ByteSize(u) * size, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size: %q", s)
+	}
+	bytes := f * float64(size)
+	if bytes >= float64(^ByteSize(0)) {
+		return 0, fmt.Errorf("byte size %q overflows", s)
+	}
+	return ByteSize(bytes), nil
+}
+
+// String returns the size with the largest unit dividing it exactly, e.g. "512MB"
+func (b ByteSize) String() string {
+	units := []struct {
+		name string
+		size ByteSize
+	}{
+		{"PB", PetaByte},
+		{"TB", TeraByte},
+		{"GB", GigaByte},
+		{"MB", MegaByte},
+		{"KB", KiloByte},
+	}
+	for _, unit := range units {
+		if b >= unit.size && b%unit.size == 0 {
+			return strconv.FormatUint(uint64(b/unit.size), 10) + unit.name
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	urlType             = reflect.TypeOf(url.URL{})
+)
+
+// isTextType reports whether values of t are read from strings, i.e. t
+// implements encoding.TextUnmarshaler, like time.Time, net.IP, netip.Prefix,
+// regexp.Regexp and ByteSize, or t is url.URL
+func isTextType(t reflect.Type) bool {
+	t = derefType(t)
+	return t == urlType || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// setTextValue sets a string value on a field of a text type, it reports
+// whether the field was handled
+func setTextValue(field reflect.Value, value any, fieldPath string) (bool, error) {
+	str, ok := value.(string)
+	if !ok || !field.CanAddr() {
+		return false, nil
+	}
+
+	if field.Type() == urlType {
+		u, err := url.Parse(str)
+		if err != nil {
+			return true, fmt.Errorf("field %s cannot parse url: %w", fieldPath, err)
+		}
+		field.Set(reflect.ValueOf(*u))
+		return true, nil
+	}
+
+	unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return false, nil
+	}
+	if err := unmarshaler.UnmarshalText([]byte(str)); err != nil {
+		return true, fmt.Errorf("field %s cannot parse '%s' as %s: %w", fieldPath, str, field.Type(), err)
+	}
+	return true, nil
+}
+
+// textOf returns the text form of a value of a text type
+func textOf(v reflect.Value) (string, bool) {
+	if v.Type() == urlType {
+		u := v.Interface().(url.URL)
+		return u.String(), true
+	}
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		if text, err := marshaler.MarshalText(); err == nil {
+			return string(text), true
+		}
+	}
+	if v.CanAddr() {
+		if marshaler, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			if text, err := marshaler.MarshalText(); err == nil {
+				return string(text), true
+			}
+		}
+	}
+	return "", false
+}
@@ -1,6 +1,7 @@
 package conf
 
 import (
+	"reflect"
 	"regexp"
 	"strings"
 	"unicode"
@@ -48,47 +49,65 @@ func toSnakeCase(s string) string {
 
 // findValueInMap finds value in map using different matching modes
 func findValueInMap(m map[string]any, fieldName string, mode MatchMode) (any, bool) {
+	key, exists := findKeyInMap(m, fieldName, mode)
+	if !exists {
+		return nil, false
+	}
+	return m[key], true
+}
+
+// findKeyInMap finds the key of fieldName in map using different matching modes
+func findKeyInMap(m map[string]any, fieldName string, mode MatchMode) (string, bool) {
 	// First try exact match
-	if value, exists := m[fieldName]; exists {
-		return value, true
+	if _, exists := m[fieldName]; exists {
+		return fieldName, true
 	}
 
 	// Then try converted field name
 	convertedName := convertFieldName(fieldName, mode)
-	if value, exists := m[convertedName]; exists {
-		return value, true
+	if _, exists := m[convertedName]; exists {
+		return convertedName, true
 	}
 
 	// For case insensitive mode, try all keys
 	if mode == MatchIgnoreCase {
 		lowerFieldName := strings.ToLower(fieldName)
-		for key, value := range m {
+		for key := range m {
 			if strings.ToLower(key) == lowerFieldName {
-				return value, true
+				return key, true
 			}
 		}
 	}
 
-	return nil, false
+	return "", false
 }
 
-// getNestedValue gets nested value from map using dot notation path
-func getNestedValue(m map[string]any, path string) (any, bool) {
-	if path == "" {
-		return m, true
+// getNestedValue gets nested value from map using a path like "a.b[2].c",
+// keys are matched according to mode
+func getNestedValue(m map[string]any, path string, mode MatchMode) (any, bool) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, false
 	}
 
-	parts := strings.Split(path, ".")
 	current := any(m)
-
-	for _, part := range parts {
-		if currentMap, ok := current.(map[string]any); ok {
-			if value, exists := currentMap[part]; exists {
-				current = value
-			} else {
+	for _, segment := range segments {
+		switch v := current.(type) {
+		case map[string]any:
+			if segment.isIndex {
 				return nil, false
 			}
-		} else {
+			value, exists := findValueInMap(v, segment.key, mode)
+			if !exists {
+				return nil, false
+			}
+			current = value
+		case []any:
+			if !segment.isIndex || segment.index >= len(v) {
+				return nil, false
+			}
+			current = v[segment.index]
+		default:
 			return nil, false
 		}
 	}
@@ -124,6 +143,87 @@ func setNestedValue(m map[string]any, path string, value any) {
 	current[lastPart] = value
 }
 
+// walkFields walks the fields of struct type t and calls fn for every leaf
+// field with its tag path, nested and embedded structs are descended into.
+// A struct type already on the current path is not walked again, so
+// recursive types end at their first repetition.
+func walkFields(t reflect.Type, option *Option, path []string, fn func(path []string, field reflect.StructField, tagInfo *TagInfo)) {
+	walkStructFields(t, option, path, make(map[reflect.Type]bool), fn)
+}
+
+// walkStructFields walks t like walkFields, ancestors holds the struct types
+// on the current path
+func walkStructFields(t reflect.Type, option *Option, path []string, ancestors map[reflect.Type]bool, fn func(path []string, field reflect.StructField, tagInfo *TagInfo)) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || ancestors[t] {
+		return
+	}
+	ancestors[t] = true
+	defer delete(ancestors, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldType.Tag.Get(option.TagName))
+		if tagInfo.Skip {
+			continue
+		}
+
+		if fieldType.Anonymous {
+			walkStructFields(fieldType.Type, option, path, ancestors, fn)
+			continue
+		}
+
+		fieldName := fieldType.Name
+		if tagInfo.FieldName != "" {
+			fieldName = tagInfo.FieldName
+		}
+		fieldPath := append(path[:len(path):len(path)], fieldName)
+
+		if isNestedStruct(fieldType.Type) {
+			walkStructFields(fieldType.Type, option, fieldPath, ancestors, fn)
+			continue
+		}
+
+		fn(fieldPath, fieldType, tagInfo)
+	}
+}
+
+// isListType reports whether t is a slice set from comma separated lists
+func isListType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 && !isTextType(t)
+}
+
+// setPathValue sets value in map following path parts, existing keys are
+// matched according to mode so the override lands on the key the file uses.
+// It returns the dotted path of the keys actually used.
+func setPathValue(m map[string]any, parts []string, value any, mode MatchMode) string {
+	current := m
+	path := ""
+	for _, part := range parts[:len(parts)-1] {
+		key := matchKey(current, part, mode)
+		next, ok := current[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			current[key] = next
+		}
+		current = next
+		path = joinPath(path, key)
+	}
+
+	last := matchKey(current, parts[len(parts)-1], mode)
+	current[last] = value
+	return joinPath(path, last)
+}
+
 // isZeroValue checks if a value is zero value
 func isZeroValue(v any) bool {
 	if v == nil {
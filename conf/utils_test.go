@@ -0,0 +1,47 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type walkNode struct {
+	Name     string      `meta:"name"`
+	Next     *walkNode   `meta:"next,optional"`
+	Children []*walkNode `meta:"children,optional"`
+	Pair     walkPair    `meta:"pair"`
+}
+
+type walkPair struct {
+	Value string    `meta:"value"`
+	Node  *walkNode `meta:"node,optional"`
+}
+
+func TestWalkFieldsRecursive(t *testing.T) {
+	var paths []string
+	walkFields(reflect.TypeOf(walkNode{}), NewOption(), nil, func(path []string, field reflect.StructField, tagInfo *TagInfo) {
+		paths = append(paths, strings.Join(path, "."))
+	})
+
+	want := []string{"name", "children", "pair.value"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("got %v, want %v", paths, want)
+	}
+}
+
+func TestLoadRecursiveWithAutoEnv(t *testing.T) {
+	t.Setenv("WALK_NAME", "env")
+
+	registry := NewRegistry()
+	defer registry.Close()
+
+	v, err := LoadFromJson[walkNode]([]byte(`{"name":"a","next":{"name":"b","pair":{"value":"y"}},"pair":{"value":"x"}}`),
+		WithAutoEnv("WALK"), WithRegistry(registry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "env" || v.Next == nil || v.Next.Name != "b" {
+		t.Fatalf("got %+v", v)
+	}
+}
@@ -6,19 +6,115 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
+
+	"github.com/meta-apex/gopkg/cast"
 )
 
+// Validator is implemented by config structs which validate themselves,
+// Validate is called on the target and every nested struct after mapping
+type Validator interface {
+	Validate() error
+}
+
 // TagInfo represents parsed tag information
 type TagInfo struct {
 	FieldName string   // Custom field name
-	Default   string   // Default value
+	Default   string   // Default value, quote it to use commas like default='a,b'
 	Options   []string // Valid options
 	RangeMin  *float64 // Range minimum
 	RangeMax  *float64 // Range maximum
 	Watch     bool     // Whether to watch for changes
 	Optional  bool     // Whether field is optional
 	Skip      bool     // Whether to skip this field
+	Env       string   // Environment variable overriding this field
+	Usage     string   // Help text of this field, quote it to use commas like usage='host, without port'
+
+	Pattern      string   // Regular expression strings must match, quote it to use commas like pattern='^[0-9]{3,5}$'
+	LenMin       *float64 // Minimum length of strings, slices and maps
+	LenMax       *float64 // Maximum length of strings, slices and maps
+	Unique       bool     // Whether slice elements must be unique
+	RequiredIf   string   // Sibling condition like Mode=file|volume making this field required
+	RequiredWith []string // Siblings making this field required when any of them is set
+	Validators   []string // Names of custom validators, see RegisterValidator
+	Secret       bool     // Whether the value is redacted in dumps
+
+	Err error // First malformed or unknown tag part, reported when mapping with Option.StrictTags
+}
+
+// FieldError is a violation of a validation rule by a single field
+type FieldError struct {
+	Path    string // Field path, e.g. Servers[0].Host
+	Rule    string // Violated rule, e.g. required, options, range, pattern
+	Message string // Human readable description
+}
+
+// Error implements error
+func (e *FieldError) Error() string {
+	return "field " + e.Path + " " + e.Message
+}
+
+// newFieldError creates a FieldError
+func newFieldError(path, rule, format string, args ...any) *FieldError {
+	return &FieldError{Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidationErrors lists every violation found while mapping a config
+type ValidationErrors []error
+
+// Error implements error
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d config errors:", len(e))
+	for _, err := range e {
+		sb.WriteString("\n\t")
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap returns the collected errors, so errors.As finds a FieldError
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// append adds err to e, flattening nested ValidationErrors
+func (e ValidationErrors) append(err error) ValidationErrors {
+	if err == nil {
+		return e
+	}
+	if errs, ok := err.(ValidationErrors); ok {
+		return append(e, errs...)
+	}
+	return append(e, err)
+}
+
+// err returns e as error, or nil if it is empty
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+var (
+	validatorsMu     sync.RWMutex
+	customValidators = make(map[string]func(value any) error)
+	patternCache     sync.Map
+)
+
+// RegisterValidator registers a custom validator referenced by the
+// validate=name1|name2 tag part, fn receives the typed field value
+func RegisterValidator(name string, fn func(value any) error) {
+	validatorsMu.Lock()
+	customValidators[name] = fn
+	validatorsMu.Unlock()
 }
 
 // parseTag parses struct tag and returns TagInfo
@@ -35,7 +131,11 @@ func parseTag(tag string) *TagInfo {
 		return info
 	}
 
-	parts := strings.Split(tag, ",")
+	parts, err := splitTag(tag)
+	if err != nil {
+		info.Err = err
+		parts = strings.Split(tag, ",")
+	}
 
 	// First part might be field name
 	if len(parts) > 0 && parts[0] != "" {
@@ -51,28 +151,88 @@ func parseTag(tag string) *TagInfo {
 
 		switch {
 		case strings.HasPrefix(part, "default="):
-			info.Default = strings.TrimPrefix(part, "default=")
+			info.Default = unquoteTagValue(strings.TrimPrefix(part, "default="))
 		case strings.HasPrefix(part, "options="):
 			optionsStr := strings.TrimPrefix(part, "options=")
 			info.Options = strings.Split(optionsStr, "|")
 		case strings.HasPrefix(part, "range="):
 			parseRange(strings.TrimPrefix(part, "range="), info)
+		case strings.HasPrefix(part, "len="):
+			info.LenMin, info.LenMax = parseBounds(strings.TrimPrefix(part, "len="))
+		case strings.HasPrefix(part, "pattern="):
+			info.Pattern = unquoteTagValue(strings.TrimPrefix(part, "pattern="))
+		case strings.HasPrefix(part, "required_if="):
+			info.RequiredIf = strings.TrimPrefix(part, "required_if=")
+		case strings.HasPrefix(part, "required_with="):
+			info.RequiredWith = strings.Split(strings.TrimPrefix(part, "required_with="), "|")
+		case strings.HasPrefix(part, "validate="):
+			info.Validators = strings.Split(strings.TrimPrefix(part, "validate="), "|")
+		case part == "unique":
+			info.Unique = true
+		case part == "secret":
+			info.Secret = true
+		case strings.HasPrefix(part, "usage="):
+			info.Usage = unquoteTagValue(strings.TrimPrefix(part, "usage="))
+		case strings.HasPrefix(part, "env="):
+			info.Env = strings.TrimPrefix(part, "env=")
 		case part == "watch":
 			info.Watch = true
 		case part == "optional":
 			info.Optional = true
+		default:
+			if info.Err == nil {
+				info.Err = fmt.Errorf("unknown tag part %q", part)
+			}
 		}
 	}
 
 	return info
 }
 
+// splitTag splits tag on commas, a value starting with a single quote
+// extends to the closing quote followed by a comma or the end of the tag
+func splitTag(tag string) ([]string, error) {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case quoted:
+			if tag[i] == '\'' && (i+1 == len(tag) || tag[i+1] == ',') {
+				quoted = false
+			}
+		case tag[i] == '\'' && i > start && tag[i-1] == '=':
+			quoted = true
+		case tag[i] == ',':
+			parts = append(parts, tag[start:i])
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in tag %q", tag)
+	}
+	return append(parts, tag[start:]), nil
+}
+
+// unquoteTagValue removes the single quotes around a tag value
+func unquoteTagValue(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
 // parseRange parses range specification like [0:100], (0:100], [0:100), (0:100)
 func parseRange(rangeStr string, info *TagInfo) {
-	rangeRegex := regexp.MustCompile(`^([\[\(])([^:]*):([^\]\)]*)([\]\)])$`)
+	info.RangeMin, info.RangeMax = parseBounds(rangeStr)
+}
+
+var rangeRegex = regexp.MustCompile(`^([\[\(])([^:]*):([^\]\)]*)([\]\)])$`)
+
+// parseBounds parses the bounds of a range specification, missing bounds are nil
+func parseBounds(rangeStr string) (lower, upper *float64) {
 	matches := rangeRegex.FindStringSubmatch(rangeStr)
 	if len(matches) != 5 {
-		return
+		return nil, nil
 	}
 
 	minStr := strings.TrimSpace(matches[2])
@@ -81,24 +241,29 @@ func parseRange(rangeStr string, info *TagInfo) {
 	// Parse min value
 	if minStr != "" {
 		if fMin, err := strconv.ParseFloat(minStr, 64); err == nil {
-			info.RangeMin = &fMin
+			lower = &fMin
 		}
 	}
 
 	// Parse max value
 	if maxStr != "" {
 		if fMax, err := strconv.ParseFloat(maxStr, 64); err == nil {
-			info.RangeMax = &fMax
+			upper = &fMax
 		}
 	}
+
+	return lower, upper
 }
 
-// validateValue validates value according to tag rules
+// validateValue validates value according to tag rules, all violated rules are reported.
+// Optional fields left at the zero value are not validated, set ones are.
 func validateValue(value any, tagInfo *TagInfo, fieldPath string) error {
-	if tagInfo.Skip || tagInfo.Optional {
+	if tagInfo.Skip || tagInfo.Optional && isZeroValue(value) {
 		return nil
 	}
 
+	var errs ValidationErrors
+
 	// Validate options
 	if len(tagInfo.Options) > 0 {
 		valueStr := fmt.Sprintf("%v", value)
@@ -110,20 +275,200 @@ func validateValue(value any, tagInfo *TagInfo, fieldPath string) error {
 			}
 		}
 		if !valid {
-			return fmt.Errorf("field %s value '%v' is not in valid options: %v", fieldPath, value, tagInfo.Options)
+			errs = errs.append(newFieldError(fieldPath, "options", "value '%v' is not in valid options: %v", value, tagInfo.Options))
 		}
 	}
 
 	// Validate range for numeric values
 	if tagInfo.RangeMin != nil || tagInfo.RangeMax != nil {
-		if err := validateRange(value, tagInfo, fieldPath); err != nil {
-			return err
+		errs = errs.append(validateRange(value, tagInfo, fieldPath))
+	}
+
+	// Validate length of strings, slices and maps
+	if tagInfo.LenMin != nil || tagInfo.LenMax != nil {
+		errs = errs.append(validateLen(value, tagInfo, fieldPath))
+	}
+
+	// Validate pattern
+	if tagInfo.Pattern != "" {
+		errs = errs.append(validatePattern(value, tagInfo.Pattern, fieldPath))
+	}
+
+	// Validate unique elements
+	if tagInfo.Unique {
+		errs = errs.append(validateUnique(value, fieldPath))
+	}
+
+	return errs.err()
+}
+
+// validateLen validates the length of strings, slices and maps
+func validateLen(value any, tagInfo *TagInfo, fieldPath string) error {
+	var length int
+	switch v := value.(type) {
+	case string:
+		length = utf8.RuneCountInString(v)
+	case []any:
+		length = len(v)
+	case map[string]any:
+		length = len(v)
+	default:
+		return newFieldError(fieldPath, "len", "value '%v' has no length for len validation", value)
+	}
+
+	if tagInfo.LenMin != nil && float64(length) < *tagInfo.LenMin {
+		return newFieldError(fieldPath, "len", "length %d must be greater than or equal to %v", length, *tagInfo.LenMin)
+	}
+	if tagInfo.LenMax != nil && float64(length) > *tagInfo.LenMax {
+		return newFieldError(fieldPath, "len", "length %d must be less than or equal to %v", length, *tagInfo.LenMax)
+	}
+
+	return nil
+}
+
+// validatePattern validates value against a regular expression
+func validatePattern(value any, pattern string, fieldPath string) error {
+	var re *regexp.Regexp
+	if cached, ok := patternCache.Load(pattern); ok {
+		re = cached.(*regexp.Regexp)
+	} else {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return newFieldError(fieldPath, "pattern", "has invalid pattern %q: %v", pattern, err)
 		}
+		patternCache.Store(pattern, compiled)
+		re = compiled
+	}
+
+	check := func(s string) bool { return re.MatchString(s) }
+	var errs ValidationErrors
+	switch v := value.(type) {
+	case []any:
+		for i, item := range v {
+			if !check(fmt.Sprintf("%v", item)) {
+				errs = errs.append(newFieldError(fmt.Sprintf("%s[%d]", fieldPath, i), "pattern", "value '%v' does not match pattern %q", item, pattern))
+			}
+		}
+	default:
+		if !check(fmt.Sprintf("%v", v)) {
+			errs = errs.append(newFieldError(fieldPath, "pattern", "value '%v' does not match pattern %q", value, pattern))
+		}
+	}
+
+	return errs.err()
+}
+
+// validateUnique validates that slice elements are unique
+func validateUnique(value any, fieldPath string) error {
+	items, ok := value.([]any)
+	if !ok {
+		return newFieldError(fieldPath, "unique", "value '%v' is not a list for unique validation", value)
+	}
+
+	seen := make(map[string]int, len(items))
+	for i, item := range items {
+		key := fmt.Sprintf("%T:%v", item, item)
+		if j, exists := seen[key]; exists {
+			return newFieldError(fieldPath, "unique", "elements %d and %d are duplicated: '%v'", j, i, item)
+		}
+		seen[key] = i
 	}
 
 	return nil
 }
 
+// runCustomValidators runs the custom validators named by the tag on the typed field value
+func runCustomValidators(field reflect.Value, tagInfo *TagInfo, fieldPath string) error {
+	if len(tagInfo.Validators) == 0 {
+		return nil
+	}
+
+	var errs ValidationErrors
+	for _, name := range tagInfo.Validators {
+		validatorsMu.RLock()
+		fn, ok := customValidators[name]
+		validatorsMu.RUnlock()
+
+		if !ok {
+			errs = errs.append(newFieldError(fieldPath, name, "uses unknown validator %q", name))
+			continue
+		}
+		if err := fn(field.Interface()); err != nil {
+			errs = errs.append(newFieldError(fieldPath, name, "%v", err))
+		}
+	}
+
+	return errs.err()
+}
+
+// validateCrossFields validates required_if and required_with rules of struct v
+func validateCrossFields(v reflect.Value, option *Option, basePath string) error {
+	t := v.Type()
+
+	var errs ValidationErrors
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldType.Tag.Get(option.TagName))
+		if tagInfo.RequiredIf == "" && len(tagInfo.RequiredWith) == 0 {
+			continue
+		}
+		if !v.Field(i).IsZero() {
+			continue
+		}
+
+		fieldName := fieldType.Name
+		if tagInfo.FieldName != "" {
+			fieldName = tagInfo.FieldName
+		}
+		fieldPath := fieldName
+		if basePath != "" {
+			fieldPath = basePath + "." + fieldName
+		}
+
+		if tagInfo.RequiredIf != "" {
+			name, values, _ := strings.Cut(tagInfo.RequiredIf, "=")
+			if sibling, ok := siblingField(v, name, option); ok {
+				actual := fmt.Sprintf("%v", sibling.Interface())
+				for _, want := range strings.Split(values, "|") {
+					if actual == want {
+						errs = errs.append(newFieldError(fieldPath, "required_if", "is required when %s is '%s'", name, actual))
+						break
+					}
+				}
+			}
+		}
+
+		for _, name := range tagInfo.RequiredWith {
+			if sibling, ok := siblingField(v, name, option); ok && !sibling.IsZero() {
+				errs = errs.append(newFieldError(fieldPath, "required_with", "is required when %s is set", name))
+				break
+			}
+		}
+	}
+
+	return errs.err()
+}
+
+// siblingField finds a field of struct v by its Go name or tag name
+func siblingField(v reflect.Value, name string, option *Option) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		tagInfo := parseTag(fieldType.Tag.Get(option.TagName))
+		if strings.EqualFold(fieldType.Name, name) || (tagInfo.FieldName != "" && strings.EqualFold(tagInfo.FieldName, name)) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
 // validateRange validates numeric range
 func validateRange(value any, tagInfo *TagInfo, fieldPath string) error {
 	var numValue float64
@@ -157,17 +502,17 @@ func validateRange(value any, tagInfo *TagInfo, fieldPath string) error {
 	case string:
 		numValue, err = strconv.ParseFloat(v, 64)
 		if err != nil {
-			return fmt.Errorf("field %s value '%v' is not a valid number for range validation", fieldPath, value)
+			return newFieldError(fieldPath, "range", "value '%v' is not a valid number for range validation", value)
 		}
 	default:
-		return fmt.Errorf("field %s value '%v' is not a numeric type for range validation", fieldPath, value)
+		return newFieldError(fieldPath, "range", "value '%v' is not a numeric type for range validation", value)
 	}
 
 	// Check minimum
 	if tagInfo.RangeMin != nil {
 		min := *tagInfo.RangeMin
 		if numValue < min {
-			return fmt.Errorf("field %s value %v must be greater than or equal to %v", fieldPath, numValue, min)
+			return newFieldError(fieldPath, "range", "value %v must be greater than or equal to %v", numValue, min)
 		}
 	}
 
@@ -175,19 +520,20 @@ func validateRange(value any, tagInfo *TagInfo, fieldPath string) error {
 	if tagInfo.RangeMax != nil {
 		max := *tagInfo.RangeMax
 		if numValue > max {
-			return fmt.Errorf("field %s value %v must be less than or equal to %v", fieldPath, numValue, max)
+			return newFieldError(fieldPath, "range", "value %v must be less than or equal to %v", numValue, max)
 		}
 	}
 
 	return nil
 }
 
-// parseDuration parses duration string, supporting both time.Duration format and milliseconds
+// parseDuration parses duration string, supporting both time.Duration format
+// with days and weeks like "2d12h" and milliseconds
 func parseDuration(value any) (time.Duration, error) {
 	switch v := value.(type) {
 	case string:
 		// Try parsing as time.Duration first
-		if duration, err := time.ParseDuration(v); err == nil {
+		if duration, err := cast.ParseDuration(v); err == nil {
 			return duration, nil
 		}
 		// Try parsing as number (milliseconds)
@@ -211,3 +557,58 @@ func parseDuration(value any) (time.Duration, error) {
 		return 0, fmt.Errorf("unsupported duration type: %T", v)
 	}
 }
+
+// runValidators runs Validator implementations and validation hooks on target
+func runValidators(target any, option *Option) error {
+	if err := callValidate(reflect.ValueOf(target), ""); err != nil {
+		return err
+	}
+
+	for _, validate := range option.Validators {
+		if err := validate(target); err != nil {
+			return fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// callValidate calls Validate on v and its nested structs, deepest first
+func callValidate(v reflect.Value, fieldPath string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		path := t.Field(i).Name
+		if fieldPath != "" {
+			path = fieldPath + "." + path
+		}
+		if err := callValidate(v.Field(i), path); err != nil {
+			return err
+		}
+	}
+
+	if v.CanAddr() {
+		if validator, ok := v.Addr().Interface().(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				if fieldPath == "" {
+					return fmt.Errorf("validation failed: %w", err)
+				}
+				return fmt.Errorf("field %s validation failed: %w", fieldPath, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package conf
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		pattern string
+		usage   string
+		def     string
+		hasErr  bool
+	}{
+		{tag: `code,pattern=^[0-9]+$`, pattern: `^[0-9]+$`},
+		{tag: `code,pattern='^[0-9]{3,5}$',optional`, pattern: `^[0-9]{3,5}$`},
+		{tag: `host,usage='host name, without port'`, usage: "host name, without port"},
+		{tag: `tags,default='a,b'`, def: "a,b"},
+		{tag: `name,default=it's`, def: "it's"},
+		{tag: `code,pattern=^[0-9]{3,5}$`, pattern: `^[0-9]{3`, hasErr: true},
+		{tag: `code,pattern='a,b`, pattern: `'a`, hasErr: true},
+		{tag: `code,unknown`, hasErr: true},
+	}
+	for _, tt := range tests {
+		info := parseTag(tt.tag)
+		if info.Pattern != tt.pattern || info.Usage != tt.usage || info.Default != tt.def {
+			t.Errorf("parseTag(%q) = pattern %q usage %q default %q", tt.tag, info.Pattern, info.Usage, info.Default)
+		}
+		if (info.Err != nil) != tt.hasErr {
+			t.Errorf("parseTag(%q) error = %v, want error %v", tt.tag, info.Err, tt.hasErr)
+		}
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		tag   string
+		value any
+		rules []string
+	}{
+		{"options ok", ",options=a|b", "a", nil},
+		{"options", ",options=a|b", "c", []string{"options"}},
+		{"range ok", ",range=[1:10]", 5, nil},
+		{"range low", ",range=[1:10]", 0, []string{"range"}},
+		{"range high", ",range=[1:10]", 11, []string{"range"}},
+		{"len ok", ",len=[2:3]", "ab", nil},
+		{"len runes", ",len=[2:3]", "日本語x", []string{"len"}},
+		{"len slice", ",len=[1:]", []any{}, []string{"len"}},
+		{"pattern ok", ",pattern=^[0-9]+$", "123", nil},
+		{"pattern", ",pattern=^[0-9]+$", "abc", []string{"pattern"}},
+		{"pattern list", ",pattern=^[0-9]+$", []any{"a", "1", "b"}, []string{"pattern", "pattern"}},
+		{"unique ok", ",unique", []any{1, 2}, nil},
+		{"unique", ",unique", []any{1, 1}, []string{"unique"}},
+		{"optional zero", ",optional,pattern=^[0-9]+$", "", nil},
+		{"optional set", ",optional,pattern=^[0-9]+$", "abc", []string{"pattern"}},
+		{"all rules", ",len=[5:],pattern=^[0-9]+$", "abc", []string{"len", "pattern"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateValue(tt.value, parseTag(tt.tag), "field")
+
+			var rules []string
+			var errs ValidationErrors
+			if errors.As(err, &errs) {
+				for _, e := range errs {
+					var fe *FieldError
+					if errors.As(e, &fe) {
+						rules = append(rules, fe.Rule)
+					}
+				}
+			} else if err != nil {
+				var fe *FieldError
+				if !errors.As(err, &fe) {
+					t.Fatalf("unexpected error %v", err)
+				}
+				rules = append(rules, fe.Rule)
+			}
+
+			if len(rules) != len(tt.rules) {
+				t.Fatalf("got rules %v (%v), want %v", rules, err, tt.rules)
+			}
+			for i := range rules {
+				if rules[i] != tt.rules[i] {
+					t.Fatalf("got rules %v, want %v", rules, tt.rules)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictTags(t *testing.T) {
+	type config struct {
+		Name string `meta:"name,default=a,unknown"`
+	}
+
+	registry := NewRegistry()
+	defer registry.Close()
+
+	v, err := LoadFromJson[config]([]byte(`{}`), WithRegistry(registry))
+	if err != nil {
+		t.Fatalf("unknown tag parts must be ignored by default: %v", err)
+	}
+	if v.Name != "a" {
+		t.Fatalf("got %q, want the default", v.Name)
+	}
+
+	if _, err := LoadFromJson[config]([]byte(`{}`), WithRegistry(NewRegistry()), WithStrictTags(true)); err == nil {
+		t.Fatal("expected an error for the unknown tag part with WithStrictTags")
+	}
+}
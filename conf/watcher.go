@@ -1,41 +1,52 @@
 package conf
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
-// FileWatcher watches file changes for hot reload
+// FileWatcher watches file changes for hot reload.
+//
+// The directories of the files are watched instead of the files themselves,
+// so editors saving by rename and Kubernetes ConfigMap updates swapping the
+// ..data symlink are noticed. Symlinked files are resolved and the
+// directories of their targets are watched as well. Events are debounced by
+// Option.Debounce, and reloads are skipped when the content is unchanged.
 type FileWatcher struct {
-	watcher  *fsnotify.Watcher
-	filePath string
-	config   *Config
-	stopCh   chan struct{}
-	running  bool
-	mu       sync.RWMutex
+	watcher *fsnotify.Watcher
+	files   []string
+	config  *Config
+	stopCh  chan struct{}
+	running bool
+	targets map[string]bool // Watched file paths and their symlink targets
+	hash    string          // Content hash of the last loaded files
+	mu      sync.RWMutex
 }
 
 // NewFileWatcher creates a new file watcher
 func NewFileWatcher(c *Config) (*FileWatcher, error) {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil, err
+	files := make([]string, 0, len(c.files))
+	for _, l := range c.files {
+		files = append(files, l.file)
 	}
 
 	fw := &FileWatcher{
-		watcher:  watcher,
-		filePath: c.file,
-		config:   c,
-		stopCh:   make(chan struct{}),
-		running:  false,
+		files:   files,
+		config:  c,
+		running: false,
 	}
 
 	return fw, nil
 }
 
-// Start starts watching the file
+// Start starts watching the files
 func (fw *FileWatcher) Start() error {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
@@ -44,14 +55,22 @@ func (fw *FileWatcher) Start() error {
 		return nil
 	}
 
-	err := fw.watcher.Add(fw.filePath)
+	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
 	}
+	fw.watcher = watcher
+	fw.targets = make(map[string]bool)
+	if err := fw.addWatches(); err != nil {
+		watcher.Close()
+		return err
+	}
+	fw.hash = fw.contentHash()
 
+	fw.stopCh = make(chan struct{})
 	fw.running = true
 
-	go fw.watchLoop()
+	go fw.watchLoop(fw.watcher, fw.stopCh)
 
 	return nil
 }
@@ -78,57 +97,146 @@ func (fw *FileWatcher) IsRunning() bool {
 	return fw.running
 }
 
+// watchedFiles returns the layer files and the files they include
+func (fw *FileWatcher) watchedFiles() []string {
+	return append(fw.files[:len(fw.files):len(fw.files)], fw.config.includedFiles()...)
+}
+
+// addWatches watches the directories of the files and of their symlink
+// targets, it is called again after every change to follow renames,
+// symlink swaps and new includes
+func (fw *FileWatcher) addWatches() error {
+	for _, file := range fw.watchedFiles() {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		paths := []string{path}
+		if target, err := filepath.EvalSymlinks(path); err == nil && target != path {
+			paths = append(paths, target)
+		}
+
+		for _, p := range paths {
+			fw.targets[p] = true
+			if err := fw.watcher.Add(filepath.Dir(p)); err != nil {
+				// Directories of optional profile overlays may not exist
+				if os.IsNotExist(err) {
+					continue
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isRelevant reports whether event may change the watched files
+func (fw *FileWatcher) isRelevant(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	if isSymlinkSwap(event.Name) {
+		return true
+	}
+
+	name, err := filepath.Abs(event.Name)
+	if err != nil {
+		return false
+	}
+	fw.mu.RLock()
+	defer fw.mu.RUnlock()
+	return fw.targets[name]
+}
+
+// contentHash returns the hash of the contents of the watched files
+func (fw *FileWatcher) contentHash() string {
+	return hashFiles(fw.watchedFiles())
+}
+
+// hashFiles returns the hash of the names and contents of files,
+// read errors are hashed too so a vanished file counts as a change
+func hashFiles(files []string) string {
+	h := sha256.New()
+	for _, file := range files {
+		h.Write([]byte(file))
+		h.Write([]byte{0})
+		if data, err := os.ReadFile(file); err == nil {
+			h.Write(data)
+		} else {
+			h.Write([]byte(err.Error()))
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // watchLoop is the main watch loop
-func (fw *FileWatcher) watchLoop() {
-	// Debounce timer to avoid multiple rapid file changes
-	var timer *time.Timer
-	var timerMu sync.Mutex
+func (fw *FileWatcher) watchLoop(watcher *fsnotify.Watcher, stopCh chan struct{}) {
+	// Debounce to coalesce rapid file changes
+	var debounce <-chan time.Time
 
 	for {
 		select {
-		case event, ok := <-fw.watcher.Events:
+		case event, ok := <-watcher.Events:
 			if !ok {
 				return
 			}
-
-			// Only handle write and create events
-			if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
-				// Debounce file changes
-				timerMu.Lock()
-				if timer != nil {
-					timer.Stop()
-				}
-				timer = time.AfterFunc(100*time.Millisecond, func() {
-					fw.reloadConfig()
-				})
-				timerMu.Unlock()
+			if fw.isRelevant(event) {
+				debounce = time.After(fw.config.option.Debounce)
 			}
 
-		case err, ok := <-fw.watcher.Errors:
+		case <-debounce:
+			debounce = nil
+			fw.reloadConfig()
+
+		case err, ok := <-watcher.Errors:
 			if !ok {
 				return
 			}
-			// Log error but continue watching
-			_ = err // TODO: Add proper logging
+			// Report error but continue watching
+			fw.config.reportError(fmt.Errorf("failed to watch config files: %w", err))
 
-		case <-fw.stopCh:
+		case <-stopCh:
 			return
 		}
 	}
 }
 
-// reloadConfig reloads configuration from file
+// reloadConfig reloads configuration from files when their content changed
 func (fw *FileWatcher) reloadConfig() {
-	// Parse the updated config file
-	newRawMap, err := parseConfigFile(fw.filePath)
-	if err != nil {
-		// TODO: Add proper error handling/logging
-		return
+	hash := fw.contentHash()
+
+	fw.mu.Lock()
+	changed := hash != fw.hash
+	fw.mu.Unlock()
+
+	if changed {
+		if err := fw.config.reload(); err != nil {
+			fw.config.reportError(err)
+		} else {
+			fw.mu.Lock()
+			fw.hash = hash
+			fw.mu.Unlock()
+		}
 	}
 
-	// Update the config
-	if err := fw.config.Update(newRawMap); err != nil {
-		// TODO: Add proper error handling/logging
+	// Follow renames, symlink swaps and new includes
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if !fw.running {
 		return
 	}
+	if err := fw.addWatches(); err != nil {
+		fw.config.reportError(fmt.Errorf("failed to watch config files: %w", err))
+	}
+}
+
+// markWritten records the current content as loaded, so the events of a
+// write by the config itself do not trigger a reload
+func (fw *FileWatcher) markWritten() {
+	hash := fw.contentHash()
+	fw.mu.Lock()
+	fw.hash = hash
+	fw.mu.Unlock()
 }
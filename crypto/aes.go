@@ -187,6 +187,46 @@ func AesDecryptOFB(data []byte, key []byte) ([]byte, error) {
 	return PKCS7UnPadding(out)
 }
 
+// AesEncryptGCM encrypts and authenticates data with AES-GCM, the random nonce
+// is prepended to the output. additionalData is authenticated but not encrypted.
+func AesEncryptGCM(data []byte, key []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, additionalData), nil
+}
+
+// AesDecryptGCM decrypts data produced by AesEncryptGCM, it fails if the key,
+// the additionalData or the ciphertext do not match.
+func AesDecryptGCM(data []byte, key []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
 // PKCS7Padding 补码
 // AES加密数据块分组长度必须为128bit(byte[16])，密钥长度可以是128bit(byte[16])、192bit(byte[24])、256bit(byte[32])中的任意一个。
 func PKCS7Padding(ciphertext []byte, blockSize int) []byte {
@@ -467,3 +467,23 @@ func (m Generic[K]) GetToTime(key K, def ...time.Time) (val time.Time, ok bool,
 	}
 	return
 }
+
+// GetStrict returns the value for the given key of Metadata or Generic converted
+// to T with cast.StrictToE, failing on overflow and precision loss instead of
+// coercing silently like the GetTo getters
+func GetStrict[T cast.Basic, K comparable](m map[K]any, key K, def ...T) (val T, ok bool, err error) {
+	if len(def) > 0 {
+		val = def[0]
+	}
+
+	v, ok := m[key]
+	if !ok {
+		return
+	}
+
+	val, err = cast.StrictToE[T](v)
+	if err != nil {
+		return
+	}
+	return
+}
@@ -34,4 +34,10 @@ type Config struct {
 	MaxSize int  `meta:",default=0"`
 	Caller  int  `meta:",default=0"`
 	Async   bool `meta:",default=false"`
+	// BufferSize represents the size in bytes of the file write buffer. 0 means no buffering.
+	// Only take effect when Mode is `file`.
+	BufferSize int `meta:",default=0"`
+	// FlushInterval represents how often in milliseconds the file write buffer is flushed, default is `1000`.
+	// Only take effect when BufferSize is positive.
+	FlushInterval int `meta:",default=1000"`
 }
@@ -64,5 +64,9 @@ func (w *ConsoleWriter) WriteEntry(e *Entry) (int, error) {
 	if out == nil {
 		out = os.Stderr
 	}
-	return w.write(out, e.buf)
+	n, err := w.write(out, e.buf)
+	if err == nil {
+		err = flushOnError(out, e.Level)
+	}
+	return n, err
 }
@@ -43,6 +43,9 @@ func (w *ConsoleWriter) WriteEntry(e *Entry) (n int, err error) {
 	} else {
 		n, err = w.writew(out, e.buf)
 	}
+	if err == nil {
+		err = flushOnError(out, e.Level)
+	}
 	return
 }
 
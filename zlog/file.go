@@ -75,6 +75,20 @@ type FileWriter struct {
 	// Cleaner specifies an optional cleanup function of zlog backups after rotation,
 	// if not set, the default behavior is to delete more than MaxBackups zlog files.
 	Cleaner func(filename string, maxBackups int, matches []os.FileInfo)
+
+	// BufferSize is the size in bytes of the in-memory write buffer. Entries are
+	// appended to the buffer and written to the file in one syscall when it fills
+	// up, when an entry of ErrorLevel or above arrives, before rotation, on Close
+	// and every FlushInterval. The default 0 disables buffering.
+	BufferSize int
+
+	// FlushInterval is the maximum time buffered entries stay in memory before
+	// being written to the file, uses 1 second as default. Only take effect when
+	// BufferSize is positive.
+	FlushInterval time.Duration
+
+	buf   []byte
+	timer *time.Timer
 }
 
 // WriteEntry implements Writer.  If a write would cause the zlog file to be larger
@@ -82,7 +96,11 @@ type FileWriter struct {
 // current time, and update symlink with zlog name file to the new file.
 func (w *FileWriter) WriteEntry(e *Entry) (n int, err error) {
 	w.mu.Lock()
-	n, err = w.write(e.buf)
+	if w.BufferSize > 0 {
+		n, err = w.bufferedWrite(e.buf, e.Level >= ErrorLevel)
+	} else {
+		n, err = w.write(e.buf)
+	}
 	w.mu.Unlock()
 	return
 }
@@ -90,13 +108,91 @@ func (w *FileWriter) WriteEntry(e *Entry) (n int, err error) {
 // Write implements io.Writer.  If a write would cause the zlog file to be larger
 // than MaxSize, the file is closed, rotate to include a timestamp of the
 // current time, and update symlink with zlog name file to the new file.
+// Writers wrapping FileWriter flush it after entries of ErrorLevel or above.
 func (w *FileWriter) Write(p []byte) (n int, err error) {
 	w.mu.Lock()
-	n, err = w.write(p)
+	if w.BufferSize > 0 {
+		n, err = w.bufferedWrite(p, false)
+	} else {
+		n, err = w.write(p)
+	}
+	w.mu.Unlock()
+	return
+}
+
+// Flush writes any buffered data to the underlying file.
+func (w *FileWriter) Flush() (err error) {
+	w.mu.Lock()
+	err = w.flush()
+	w.mu.Unlock()
+	return
+}
+
+func (w *FileWriter) bufferedWrite(p []byte, force bool) (n int, err error) {
+	if len(w.buf)+len(p) > w.BufferSize {
+		if err = w.flush(); err != nil {
+			return
+		}
+	}
+	// entries larger than the buffer bypass it, ordering is kept by the flush above.
+	if len(p) >= w.BufferSize {
+		return w.write(p)
+	}
+
+	if w.buf == nil {
+		w.buf = make([]byte, 0, w.BufferSize)
+	}
+	w.buf = append(w.buf, p...)
+	n = len(p)
+
+	if force || (w.MaxSize > 0 && w.size+int64(len(w.buf)) > w.MaxSize) {
+		err = w.flush()
+		return
+	}
+
+	if w.timer == nil {
+		interval := w.FlushInterval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		w.timer = time.AfterFunc(interval, w.flushTimer)
+	}
+	return
+}
+
+func (w *FileWriter) flushTimer() {
+	w.mu.Lock()
+	w.timer = nil
+	_ = w.flush()
 	w.mu.Unlock()
+}
+
+func (w *FileWriter) flush() (err error) {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(w.buf) == 0 {
+		return
+	}
+	n, err := w.write(w.buf)
+	// keep the unwritten bytes for the next flush
+	w.buf = w.buf[:copy(w.buf, w.buf[n:])]
 	return
 }
 
+// flushOnError flushes out after an entry of ErrorLevel or above when out is
+// buffered like FileWriter, so plain io.Writer paths keep errors durable too.
+func flushOnError(out io.Writer, level Level) error {
+	if level < ErrorLevel {
+		return nil
+	}
+	if flusher, ok := out.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
 func (w *FileWriter) write(p []byte) (n int, err error) {
 	if w.file == nil {
 		if w.Filename == "" {
@@ -131,8 +227,11 @@ func (w *FileWriter) write(p []byte) (n int, err error) {
 // Close implements io.Closer, and closes the current logfile.
 func (w *FileWriter) Close() (err error) {
 	w.mu.Lock()
+	err = w.flush()
 	if w.file != nil {
-		err = w.file.Close()
+		if err1 := w.file.Close(); err1 != nil {
+			err = err1
+		}
 		w.file = nil
 		w.size = 0
 	}
@@ -147,6 +246,10 @@ func (w *FileWriter) Close() (err error) {
 // files according to the configuration.
 func (w *FileWriter) Rotate() (err error) {
 	w.mu.Lock()
+	if err = w.flush(); err != nil {
+		w.mu.Unlock()
+		return
+	}
 	err = w.rotate()
 	w.mu.Unlock()
 	return
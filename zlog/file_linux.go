@@ -13,6 +13,11 @@ func (w *FileWriter) WriteV(iovs []syscall.Iovec) (n uintptr, err error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	// keep ordering with the entries which are still buffered.
+	if err = w.flush(); err != nil {
+		return
+	}
+
 	if w.file == nil {
 		if w.Filename == "" {
 			n, err = writev(syscall.Stderr, iovs)
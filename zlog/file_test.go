@@ -0,0 +1,64 @@
+package zlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileWriterFlushOnError(t *testing.T) {
+	tests := []struct {
+		name   string
+		writer func(fw *FileWriter) Writer
+	}{
+		{"FileWriter", func(fw *FileWriter) Writer { return fw }},
+		{"ConsoleWriter", func(fw *FileWriter) Writer { return &ConsoleWriter{Writer: fw} }},
+		{"IOWriter", func(fw *FileWriter) Writer { return IOWriter{fw} }},
+		{"MultiIOWriter", func(fw *FileWriter) Writer { return &MultiIOWriter{fw} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "app.log")
+			fw := &FileWriter{Filename: filename, BufferSize: 4096, FlushInterval: time.Hour}
+			defer fw.Close()
+			logger := Logger{Level: InfoLevel, Writer: tt.writer(fw)}
+
+			logger.Info().Msg("buffered")
+			if data, _ := os.ReadFile(filename); len(data) != 0 {
+				t.Fatalf("info entry flushed early: %q", data)
+			}
+
+			logger.Error().Msg("failed")
+			data, _ := os.ReadFile(filename)
+			if !strings.Contains(string(data), "buffered") || !strings.Contains(string(data), "failed") {
+				t.Fatalf("error entry not flushed: %q", data)
+			}
+		})
+	}
+}
+
+func TestFileWriterFlushKeepsUnwritten(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	fw := &FileWriter{Filename: filepath.Join(dir, "app.log"), BufferSize: 4096, FlushInterval: time.Hour}
+	defer fw.Close()
+
+	if _, err := fw.Write([]byte("kept\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Flush(); err == nil {
+		t.Fatal("expected flush to fail without the log directory")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(fw.Filename)
+	if string(data) != "kept\n" {
+		t.Fatalf("got %q, want the entry kept from the failed flush", data)
+	}
+}
@@ -40,7 +40,11 @@ type IOWriter struct {
 
 // WriteEntry implements Writer.
 func (w IOWriter) WriteEntry(e *Entry) (n int, err error) {
-	return w.Writer.Write(e.buf)
+	n, err = w.Writer.Write(e.buf)
+	if err == nil {
+		err = flushOnError(w.Writer, e.Level)
+	}
+	return
 }
 
 // IOWriteCloser wraps an io.IOWriteCloser to Writer.
@@ -50,7 +54,11 @@ type IOWriteCloser struct {
 
 // WriteEntry implements Writer.
 func (w IOWriteCloser) WriteEntry(e *Entry) (n int, err error) {
-	return w.WriteCloser.Write(e.buf)
+	n, err = w.WriteCloser.Write(e.buf)
+	if err == nil {
+		err = flushOnError(w.WriteCloser, e.Level)
+	}
+	return
 }
 
 // Close implements Writer.
@@ -123,17 +131,22 @@ func SetUp(c *Config) (err error) {
 			}
 			filename := c.Path + "/" + c.Name + ".log"
 			iow = &FileWriter{
-				Filename:     filename,
-				MaxSize:      int64(c.MaxSize),
-				MaxBackups:   c.MaxBackups,
-				EnsureFolder: true,
-				LocalTime:    true,
+				Filename:      filename,
+				MaxSize:       int64(c.MaxSize),
+				MaxBackups:    c.MaxBackups,
+				EnsureFolder:  true,
+				LocalTime:     true,
+				BufferSize:    c.BufferSize,
+				FlushInterval: time.Duration(c.FlushInterval) * time.Millisecond,
 			}
 		} else {
 			iow = os.Stdout
 		}
 
-		if c.Encoding == "json" {
+		if fw, ok := iow.(*FileWriter); ok && c.Encoding == "json" {
+			// use the FileWriter directly so entry levels reach its buffer.
+			w = fw
+		} else if c.Encoding == "json" {
 			w = IOWriter{iow}
 		} else if c.Encoding == "plain" {
 			if c.Mode == "console" {
@@ -131,6 +131,9 @@ func (w *MultiIOWriter) Close() (err error) {
 func (w *MultiIOWriter) WriteEntry(e *Entry) (n int, err error) {
 	for _, writer := range *w {
 		n, err = writer.Write(e.buf)
+		if err == nil {
+			err = flushOnError(writer, e.Level)
+		}
 		if err != nil {
 			return
 		}
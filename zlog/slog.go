@@ -295,6 +295,9 @@ func (h *slogJSONHandler) Handle(_ context.Context, r slog.Record) error {
 	}
 
 	_, err := h.writer.Write(e.buf)
+	if err == nil && r.Level >= slog.LevelError {
+		err = flushOnError(h.writer, ErrorLevel)
+	}
 
 	if cap(e.buf) <= bbcap {
 		epool.Put(e)